@@ -0,0 +1,179 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/recommend"
+	"github.com/go-redis/redis"
+)
+
+// Views are buffered in Redis per article and (UTC) day and flushed to the
+// database by jobs.FlushViews:
+//
+//	views:total:<id>:<day>      counter of reads not flushed yet
+//	views:unique:<id>:<day>     HyperLogLog of readers, never reset
+//	views:referrers:<id>:<day>  hash of referring host to unflushed reads
+//	views:hosts:<id>:<day>      set of the referring hosts of the day
+//	views:dirty                 set of "<id>:<day>" waiting for a flush
+const DirtyKey = "views:dirty"
+
+// DirectReferrer is recorded for reads without a (parsable) Referer.
+const DirectReferrer = "direct"
+
+// The Referer header is up to the client, so an article keeps at most
+// MaxReferrers hosts a day, the reads from any host after them are
+// recorded as OtherReferrer.
+const (
+	MaxReferrers  = 100
+	OtherReferrer = "other"
+)
+
+// BufferTTL keeps the buffers of yesterday around while its last reads are
+// flushed, the unique readers HyperLogLog needs the whole day.
+const BufferTTL = 48 * time.Hour
+
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// DateLayout is how days are written in keys, the database and the API.
+const DateLayout = "2006-01-02"
+
+func member(articleID uint, day time.Time) string {
+	return strconv.FormatUint(uint64(articleID), 10) + ":" + day.Format(DateLayout)
+}
+
+func TotalKey(articleID uint, day time.Time) string {
+	return "views:total:" + member(articleID, day)
+}
+
+func UniqueKey(articleID uint, day time.Time) string {
+	return "views:unique:" + member(articleID, day)
+}
+
+func ReferrersKey(articleID uint, day time.Time) string {
+	return "views:referrers:" + member(articleID, day)
+}
+
+func HostsKey(articleID uint, day time.Time) string {
+	return "views:hosts:" + member(articleID, day)
+}
+
+// ParseMember reverses the "<id>:<day>" entries of the dirty set.
+func ParseMember(value string) (uint, time.Time, bool) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return 0, time.Time{}, false
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	day, err := time.Parse(DateLayout, parts[1])
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return uint(id), day, true
+}
+
+// Reader identifies a reader without storing who they are, only a hash of
+// their address and browser ends up in the HyperLogLog.
+func Reader(clientIP, userAgent string) string {
+	sum := sha256.Sum256([]byte(clientIP + "|" + userAgent))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Referrer reduces a Referer header to its host.
+func Referrer(header string) string {
+	parsed, err := url.Parse(header)
+	if err != nil || parsed.Hostname() == "" {
+		return DirectReferrer
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// capReferrer returns referrer while the article has room for another host
+// that day, OtherReferrer once MaxReferrers hosts sent readers.
+func capReferrer(rdb *redis.Client, articleID uint, day time.Time, referrer string) (string, error) {
+	if referrer == DirectReferrer || referrer == OtherReferrer {
+		return referrer, nil
+	}
+
+	hostsKey := HostsKey(articleID, day)
+	pipe := rdb.TxPipeline()
+	added := pipe.SAdd(hostsKey, referrer)
+	hosts := pipe.SCard(hostsKey)
+	pipe.Expire(hostsKey, BufferTTL)
+	if _, err := pipe.Exec(); err != nil {
+		return referrer, err
+	}
+	if added.Val() == 1 && hosts.Val() > MaxReferrers {
+		rdb.SRem(hostsKey, referrer)
+		return OtherReferrer, nil
+	}
+	return referrer, nil
+}
+
+// RecordView counts one read of an article. It only touches Redis so reads
+// stay cheap, the trending views are counted here as well.
+func RecordView(rdb *redis.Client, articleID uint, reader, referrer string) error {
+	now := time.Now()
+	day := Day(now)
+	trendingKey := recommend.ViewsKey(now)
+
+	referrer, err := capReferrer(rdb, articleID, day, referrer)
+	if err != nil {
+		return err
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Incr(TotalKey(articleID, day))
+	pipe.Expire(TotalKey(articleID, day), BufferTTL)
+	pipe.PFAdd(UniqueKey(articleID, day), reader)
+	pipe.Expire(UniqueKey(articleID, day), BufferTTL)
+	pipe.HIncrBy(ReferrersKey(articleID, day), referrer, 1)
+	pipe.Expire(ReferrersKey(articleID, day), BufferTTL)
+	pipe.SAdd(DirtyKey, member(articleID, day))
+	pipe.ZIncrBy(trendingKey, 1, strconv.FormatUint(uint64(articleID), 10))
+	pipe.Expire(trendingKey, recommend.TrendingWindow+24*time.Hour)
+	_, err = pipe.Exec()
+	return err
+}
+
+// Pending is what Redis holds for an article and day that has not been
+// flushed yet. UniqueReaders is the count of the whole day.
+type Pending struct {
+	Views         int64
+	UniqueReaders int64
+	Referrers     map[string]int64
+}
+
+func PendingViews(rdb *redis.Client, articleID uint, day time.Time) (Pending, error) {
+	pending := Pending{Referrers: map[string]int64{}}
+
+	views, err := rdb.Get(TotalKey(articleID, day)).Int64()
+	if err != nil && err != redis.Nil {
+		return pending, err
+	}
+	pending.Views = views
+
+	if pending.UniqueReaders, err = rdb.PFCount(UniqueKey(articleID, day)).Result(); err != nil {
+		return pending, err
+	}
+
+	referrers, err := rdb.HGetAll(ReferrersKey(articleID, day)).Result()
+	if err != nil {
+		return pending, err
+	}
+	for referrer, count := range referrers {
+		if n, err := strconv.ParseInt(count, 10, 64); err == nil {
+			pending.Referrers[referrer] = n
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemberRoundTrip(t *testing.T) {
+	day := Day(time.Date(2024, 5, 10, 23, 30, 0, 0, time.FixedZone("WIB", 7*60*60)))
+	assert.Equal(t, "views:total:42:2024-05-10", TotalKey(42, day))
+
+	articleID, parsed, ok := ParseMember(member(42, day))
+	assert.True(t, ok)
+	assert.Equal(t, uint(42), articleID)
+	assert.True(t, parsed.Equal(day))
+
+	_, _, ok = ParseMember("42")
+	assert.False(t, ok)
+}
+
+func TestReferrer(t *testing.T) {
+	assert.Equal(t, "news.ycombinator.com", Referrer("https://News.ycombinator.com/item?id=1"))
+	assert.Equal(t, DirectReferrer, Referrer(""))
+	assert.Equal(t, DirectReferrer, Referrer("not a url"))
+}
+
+func TestReader(t *testing.T) {
+	assert.Equal(t, Reader("10.0.0.1", "curl/8.0"), Reader("10.0.0.1", "curl/8.0"))
+	assert.NotEqual(t, Reader("10.0.0.1", "curl/8.0"), Reader("10.0.0.2", "curl/8.0"))
+	assert.NotContains(t, Reader("10.0.0.1", "curl/8.0"), "10.0.0.1")
+}
+
+func TestRecordViewCapsReferrers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	for i := 0; i < MaxReferrers+5; i++ {
+		assert.NoError(t, RecordView(rdb, 7, "reader", fmt.Sprintf("host-%d.example.com", i)))
+	}
+	// Hosts seen before the cap keep counting, so does the direct traffic.
+	assert.NoError(t, RecordView(rdb, 7, "reader", "host-0.example.com"))
+	assert.NoError(t, RecordView(rdb, 7, "reader", DirectReferrer))
+
+	pending, err := PendingViews(rdb, 7, Day(time.Now()))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(MaxReferrers+7), pending.Views)
+	assert.Equal(t, int64(1), pending.UniqueReaders)
+	assert.Len(t, pending.Referrers, MaxReferrers+2)
+	assert.Equal(t, int64(5), pending.Referrers[OtherReferrer])
+	assert.Equal(t, int64(2), pending.Referrers["host-0.example.com"])
+	assert.Equal(t, int64(1), pending.Referrers[DirectReferrer])
+	assert.Zero(t, pending.Referrers[fmt.Sprintf("host-%d.example.com", MaxReferrers)])
+}
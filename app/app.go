@@ -0,0 +1,85 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"sync/atomic"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/storage"
+	"github.com/go-redis/redis"
+	"gopkg.in/danilopolani/gocialite.v1"
+	"gorm.io/gorm"
+)
+
+// App holds everything handlers, jobs and commands share. Each App has its
+// own connections, so several can live in one process, e.g. one per test.
+type App struct {
+	Config   *config.Config
+	DB       *gorm.DB
+	Replicas *config.Replicas
+	RDB      *redis.Client
+	Storage  storage.BlobStore
+	Gocial   *gocialite.Dispatcher
+	Logger   *slog.Logger
+
+	// Draining is set once the server starts shutting down, readiness
+	// fails from then on.
+	Draining atomic.Bool
+}
+
+// New connects to the database, Redis and the storage of cfg and, unless
+// DB_MIGRATE_ON_START=false, brings the schema up to date. The app logs to
+// stdout as set by the LOG_* settings.
+func New(cfg *config.Config) (*App, error) {
+	app := &App{
+		Config: cfg,
+		Gocial: gocialite.NewDispatcher(),
+		Logger: cfg.Log.NewLogger(os.Stdout),
+	}
+
+	var err error
+	if app.DB, err = config.ConnectDB(cfg, app.Logger); err != nil {
+		return nil, err
+	}
+	if cfg.DB.MigrateOnStart {
+		if err := config.MigrateDB(app.DB, cfg.DB.Driver); err != nil {
+			app.Close()
+			return nil, err
+		}
+	}
+	if app.RDB, err = config.ConnectRedis(cfg, app.Logger); err != nil {
+		app.Close()
+		return nil, err
+	}
+	if app.Storage, err = config.OpenStorage(cfg); err != nil {
+		app.Close()
+		return nil, err
+	}
+	if app.Replicas, err = config.ConnectReplicas(cfg, app.DB, app.RDB, app.Logger); err != nil {
+		app.Close()
+		return nil, err
+	}
+	return app, nil
+}
+
+// Reader is where a read only query of userID goes, see config.Replicas.
+func (app *App) Reader(userID uint) *gorm.DB {
+	return app.Replicas.Reader(userID)
+}
+
+// Close closes the connections of the app, waiting for running queries.
+func (app *App) Close() error {
+	var errs []error
+	if app.Replicas != nil {
+		app.Replicas.Close()
+	}
+	if app.DB != nil {
+		errs = append(errs, config.CloseDB(app.DB))
+	}
+	if app.RDB != nil {
+		errs = append(errs, app.RDB.Close())
+	}
+	return errors.Join(errs...)
+}
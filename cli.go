@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/migrations"
+	"github.com/ArdhanaGusti/Golang_api/transfer"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage:
+  go run . [command] [flags]
+
+Commands:
+  (none)   start the HTTP server
+  config   print the effective configuration with secrets redacted and check it
+  export   export articles (-format jsonl|csv|markdown -out file)
+  import   import articles (-format jsonl|csv|markdown -file file -dry-run -upsert -author-map old=new,... -default-author email)
+  migrate  manage the schema:
+             migrate up [-steps n]        apply pending migrations, all by default
+             migrate down [-steps n|-all] roll back the last migration or more
+             migrate status               list migrations and when they were applied
+             migrate create [-dir dir] name
+                                          add empty up/down files for every driver
+`
+
+// runCommand executes a CLI subcommand and returns the process exit code.
+func runCommand(args []string) int {
+	var err error
+	switch args[0] {
+	case "config":
+		err = configCommand()
+	case "export":
+		err = exportCommand(args[1:])
+	case "import":
+		err = importCommand(args[1:])
+	case "migrate":
+		err = migrateCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// configCommand prints the configuration the server would run with, then
+// what is wrong with it, if anything.
+func configCommand() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// loadConfig loads and validates the configuration like the server does,
+// returning what is wrong instead of panicking. Commands only call it once
+// their flags parsed and only when they connect to something.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// cliLogger logs to stderr, stdout is left to what a command outputs.
+func cliLogger(cfg *config.Config) *slog.Logger {
+	return cfg.Log.NewLogger(os.Stderr)
+}
+
+// connectDB connects like the server does, migrating first unless
+// DB_MIGRATE_ON_START=false.
+func connectDB(cfg *config.Config) (*gorm.DB, error) {
+	db, err := config.ConnectDB(cfg, cliLogger(cfg))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DB.MigrateOnStart {
+		if err := config.MigrateDB(db, cfg.DB.Driver); err != nil {
+			config.CloseDB(db)
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+func exportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", transfer.FormatJSONL, "jsonl, csv or markdown")
+	out := flags.String("out", "", "output file, stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !transfer.IsValidFormat(*format) {
+		return transfer.ErrUnknownFormat
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer config.CloseDB(db)
+	records, err := transfer.Export(db)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return transfer.Encode(*format, records, w)
+}
+
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", transfer.FormatJSONL, "jsonl, csv or markdown")
+	file := flags.String("file", "", "file to import, stdin when empty")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	upsert := flags.Bool("upsert", false, "update articles whose slug already exists")
+	authorMap := flags.String("author-map", "", "old@example.com=new@example.com,...")
+	defaultAuthor := flags.String("default-author", "", "email used when an author doesn't exist")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !transfer.IsValidFormat(*format) {
+		return transfer.ErrUnknownFormat
+	}
+
+	authors, err := transfer.ParseAuthorMap(*authorMap)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	records, err := transfer.Decode(*format, data)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer config.CloseDB(db)
+	report, err := transfer.Import(db, records, transfer.ImportOptions{
+		DryRun:        *dryRun,
+		Upsert:        *upsert,
+		AuthorMap:     authors,
+		DefaultAuthor: *defaultAuthor,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The API caches the article list, drop it so imports show up.
+	if !report.DryRun && report.Created+report.Updated > 0 && cfg.Redis.Address != "" {
+		if rdb, err := config.ConnectRedis(cfg, cliLogger(cfg)); err == nil {
+			rdb.Del("articles")
+			rdb.Close()
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate needs one of up, down, status or create")
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	steps := flags.Int("steps", 0, "how many migrations to apply or roll back")
+	all := flags.Bool("all", false, "roll back every migration")
+	dir := flags.String("dir", migrations.Dir, "directory holding the migration files")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	// create only writes files, the others need the database.
+	var cfg *config.Config
+	switch args[0] {
+	case "up", "down", "status":
+		var err error
+		if cfg, err = loadConfig(); err != nil {
+			return err
+		}
+	}
+
+	switch args[0] {
+	case "up":
+		db, err := config.ConnectDB(cfg, cliLogger(cfg))
+		if err != nil {
+			return err
+		}
+		defer config.CloseDB(db)
+		done, err := migrations.Up(db, cfg.DB.Driver, *steps)
+		for _, migration := range done {
+			fmt.Println("Applied " + migration.String())
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("Nothing to migrate")
+		}
+		return err
+	case "down":
+		if !*all && *steps == 0 {
+			*steps = 1
+		}
+		db, err := config.ConnectDB(cfg, cliLogger(cfg))
+		if err != nil {
+			return err
+		}
+		defer config.CloseDB(db)
+		done, err := migrations.Down(db, cfg.DB.Driver, *steps)
+		for _, migration := range done {
+			fmt.Println("Rolled back " + migration.String())
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("Nothing to roll back")
+		}
+		return err
+	case "status":
+		db, err := config.ConnectDB(cfg, cliLogger(cfg))
+		if err != nil {
+			return err
+		}
+		defer config.CloseDB(db)
+		statuses, err := migrations.Statuses(db, cfg.DB.Driver)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Missing {
+				appliedAt += " (file missing)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	case "create":
+		if flags.NArg() != 1 {
+			return errors.New("migrate create needs a name")
+		}
+		paths, err := migrations.Create(*dir, flags.Arg(0), time.Now())
+		for _, path := range paths {
+			fmt.Println("Created " + path)
+		}
+		return err
+	}
+	return errors.New("unknown migrate command " + args[0])
+}
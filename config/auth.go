@@ -1,7 +0,0 @@
-package config
-
-import (
-	"gopkg.in/danilopolani/gocialite.v1"
-)
-
-var Gocial = gocialite.NewDispatcher()
@@ -0,0 +1,429 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/logging"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	minSecretLength = 32
+	redacted        = "******"
+)
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" env:"SERVER_ADDR"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	TLSCertFile       string        `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile        string        `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+}
+
+type DBConfig struct {
+	Driver            string        `yaml:"driver" env:"DB_DRIVER"`
+	DSN               string        `yaml:"dsn" env:"DB_DSN" secret:"true"`
+	Username          string        `yaml:"username" env:"DB_USERNAME"`
+	Password          string        `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Host              string        `yaml:"host" env:"DB_HOST"`
+	Port              string        `yaml:"port" env:"DB_PORT"`
+	Name              string        `yaml:"name" env:"DB_NAME"`
+	SSLMode           string        `yaml:"sslmode" env:"DB_SSLMODE"`
+	MigrateOnStart    bool          `yaml:"migrate_on_start" env:"DB_MIGRATE_ON_START"`
+	MaxOpenConns      int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns      int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime   time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime   time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	ConnectRetries    int           `yaml:"connect_retries" env:"DB_CONNECT_RETRIES"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" env:"DB_CONNECT_BACKOFF"`
+	ReplicaDSNs       []string      `yaml:"replica_dsns" env:"DB_REPLICA_DSNS" secret:"true"`
+	ReplicaStickiness time.Duration `yaml:"replica_stickiness" env:"DB_REPLICA_STICKINESS"`
+}
+
+type RedisConfig struct {
+	Address  string `yaml:"address" env:"REDIS_ADDRESS"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+}
+
+type AuthConfig struct {
+	JWTSecret          string `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	RedirectURL        string `yaml:"redirect_url" env:"AUTH_REDIRECT_URL"`
+	GithubClientID     string `yaml:"github_client_id" env:"CLIENT_ID_GH"`
+	GithubClientSecret string `yaml:"github_client_secret" env:"CLIENT_SECRET_GH" secret:"true"`
+	GoogleClientID     string `yaml:"google_client_id" env:"CLIENT_ID_GO"`
+	GoogleClientSecret string `yaml:"google_client_secret" env:"CLIENT_SECRET_GO" secret:"true"`
+}
+
+type StorageConfig struct {
+	Path               string `yaml:"path" env:"STORAGE_PATH"`
+	UploadMaxSize      int64  `yaml:"upload_max_size" env:"UPLOAD_MAX_SIZE"`
+	ImportMaxSize      int64  `yaml:"import_max_size" env:"IMPORT_MAX_SIZE"`
+	TrashRetentionDays int    `yaml:"trash_retention_days" env:"TRASH_RETENTION_DAYS"`
+}
+
+type MailConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     string `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+	// LogMessages logs mail instead of failing when SMTP_HOST is unset, for
+	// local development only: messages hold verification tokens.
+	LogMessages bool `yaml:"log_messages" env:"SMTP_LOG_MESSAGES"`
+}
+
+type CacheConfig struct {
+	Public  string `yaml:"public" env:"CACHE_CONTROL_PUBLIC"`
+	Private string `yaml:"private" env:"CACHE_CONTROL_PRIVATE"`
+}
+
+type FeedConfig struct {
+	Title     string `yaml:"title" env:"FEED_TITLE"`
+	ItemLimit int    `yaml:"item_limit" env:"FEED_ITEM_LIMIT"`
+	// SitemapPageSize is the number of URLs per sitemap file, at most the
+	// 50k the protocol allows.
+	SitemapPageSize int `yaml:"sitemap_page_size" env:"SITEMAP_PAGE_SIZE"`
+}
+
+type LogConfig struct {
+	Level      string        `yaml:"level" env:"LOG_LEVEL"`
+	Format     string        `yaml:"format" env:"LOG_FORMAT"`
+	DBLevel    string        `yaml:"db_level" env:"LOG_DB_LEVEL"`
+	RedisLevel string        `yaml:"redis_level" env:"LOG_REDIS_LEVEL"`
+	SlowQuery  time.Duration `yaml:"slow_query" env:"LOG_SLOW_QUERY"`
+}
+
+// Config is every setting of the API. It is filled from the defaults, then
+// the YAML file in CONFIG_FILE, then the environment and .env, each one
+// overriding the one before.
+type Config struct {
+	AppURL         string        `yaml:"app_url" env:"APP_URL"`
+	ArticleBaseURL string        `yaml:"article_url" env:"ARTICLE_URL"`
+	MetricsToken   string        `yaml:"metrics_token" env:"METRICS_TOKEN" secret:"true"`
+	Server         ServerConfig  `yaml:"server"`
+	DB             DBConfig      `yaml:"db"`
+	Redis          RedisConfig   `yaml:"redis"`
+	Auth           AuthConfig    `yaml:"auth"`
+	Storage        StorageConfig `yaml:"storage"`
+	Mail           MailConfig    `yaml:"mail"`
+	Cache          CacheConfig   `yaml:"cache_control"`
+	Feed           FeedConfig    `yaml:"feed"`
+	Log            LogConfig     `yaml:"log"`
+}
+
+func Defaults() *Config {
+	return &Config{
+		AppURL: "http://localhost:8080",
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		DB: DBConfig{
+			Driver:            DriverMySQL,
+			SSLMode:           "disable",
+			MigrateOnStart:    true,
+			MaxOpenConns:      25,
+			MaxIdleConns:      10,
+			ConnMaxLifetime:   30 * time.Minute,
+			ConnMaxIdleTime:   5 * time.Minute,
+			ConnectRetries:    5,
+			ConnectBackoff:    time.Second,
+			ReplicaStickiness: 10 * time.Second,
+		},
+		Storage: StorageConfig{
+			Path:               "./uploads",
+			UploadMaxSize:      5 << 20,
+			ImportMaxSize:      32 << 20,
+			TrashRetentionDays: 30,
+		},
+		Mail: MailConfig{
+			Port: "587",
+		},
+		Cache: CacheConfig{
+			Public:  "public, max-age=60, stale-while-revalidate=300",
+			Private: "private, no-store",
+		},
+		Feed: FeedConfig{
+			Title:           "Golang API",
+			ItemLimit:       20,
+			SitemapPageSize: 50000,
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Format:     logging.FormatJSON,
+			DBLevel:    "warn",
+			RedisLevel: "error",
+			SlowQuery:  200 * time.Millisecond,
+		},
+	}
+}
+
+// Load reads the configuration without validating it. Call gotenv.Load
+// first for .env to count.
+func Load() (*Config, error) {
+	cfg := Defaults()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(content, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := fromEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	switch driver := strings.ToLower(cfg.DB.Driver); driver {
+	case "postgresql", "pgx":
+		cfg.DB.Driver = DriverPostgres
+	case "sqlite3":
+		cfg.DB.Driver = DriverSQLite
+	default:
+		cfg.DB.Driver = driver
+	}
+	cfg.AppURL = strings.TrimSuffix(cfg.AppURL, "/")
+	cfg.Log.Format = strings.ToLower(cfg.Log.Format)
+	return cfg, nil
+}
+
+// fromEnv overrides every field with an env tag whose variable is set.
+func fromEnv(value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		tag := value.Type().Field(i).Tag.Get("env")
+		if field.Kind() == reflect.Struct {
+			if err := fromEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+		raw := os.Getenv(tag)
+		if tag == "" || raw == "" {
+			continue
+		}
+
+		switch {
+		case field.Type() == reflect.TypeOf(time.Duration(0)):
+			duration, err := time.ParseDuration(raw)
+			if err != nil {
+				return fmt.Errorf("%s must be a duration like 30s or 5m", tag)
+			}
+			field.SetInt(int64(duration))
+		case field.Kind() == reflect.Int || field.Kind() == reflect.Int64:
+			number, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be a number", tag)
+			}
+			field.SetInt(number)
+		case field.Kind() == reflect.Bool:
+			flag, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false", tag)
+			}
+			field.SetBool(flag)
+		case field.Kind() == reflect.Slice:
+			var items []string
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			field.Set(reflect.ValueOf(items))
+		default:
+			field.SetString(raw)
+		}
+	}
+	return nil
+}
+
+// isWeakSecret rejects short secrets and long ones made of a handful of
+// characters like "aaaa..." or "123123...".
+func isWeakSecret(secret string) bool {
+	distinct := map[rune]bool{}
+	for _, char := range secret {
+		distinct[char] = true
+	}
+	return len(secret) < minSecretLength || len(distinct) < 8
+}
+
+// Validate reports every setting the API cannot run with.
+func (cfg *Config) Validate() error {
+	var errs []error
+	switch {
+	case cfg.Auth.JWTSecret == "":
+		errs = append(errs, errors.New("JWT_SECRET is required"))
+	case isWeakSecret(cfg.Auth.JWTSecret):
+		errs = append(errs, fmt.Errorf("JWT_SECRET is too weak, use at least %d random characters", minSecretLength))
+	}
+	if cfg.MetricsToken != "" && len(cfg.MetricsToken) < 16 {
+		errs = append(errs, errors.New("METRICS_TOKEN must be at least 16 characters"))
+	}
+	if cfg.Auth.GithubClientID != "" && cfg.Auth.GithubClientSecret == "" {
+		errs = append(errs, errors.New("CLIENT_SECRET_GH is required with CLIENT_ID_GH"))
+	}
+	if cfg.Auth.GoogleClientID != "" && cfg.Auth.GoogleClientSecret == "" {
+		errs = append(errs, errors.New("CLIENT_SECRET_GO is required with CLIENT_ID_GO"))
+	}
+
+	if cfg.Server.Addr == "" {
+		errs = append(errs, errors.New("SERVER_ADDR is required"))
+	}
+	if cfg.Server.ReadTimeout < 0 || cfg.Server.ReadHeaderTimeout < 0 || cfg.Server.WriteTimeout < 0 || cfg.Server.IdleTimeout < 0 || cfg.Server.ShutdownDelay < 0 || cfg.Server.ShutdownTimeout < 0 {
+		errs = append(errs, errors.New("SERVER timeouts can't be negative"))
+	}
+	if cfg.Server.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("SERVER_MAX_HEADER_BYTES must be positive"))
+	}
+	if (cfg.Server.TLSCertFile == "") != (cfg.Server.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE go together"))
+	}
+
+	switch cfg.DB.Driver {
+	case DriverMySQL, DriverPostgres, DriverSQLite:
+	default:
+		errs = append(errs, fmt.Errorf("unknown DB_DRIVER %q, use mysql, postgres or sqlite", cfg.DB.Driver))
+	}
+	if cfg.DB.MaxOpenConns < 0 || cfg.DB.MaxIdleConns < 0 || cfg.DB.ConnectRetries < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONNECT_RETRIES can't be negative"))
+	}
+	if cfg.DB.ConnMaxLifetime < 0 || cfg.DB.ConnMaxIdleTime < 0 || cfg.DB.ConnectBackoff < 0 || cfg.DB.ReplicaStickiness < 0 {
+		errs = append(errs, errors.New("DB durations can't be negative"))
+	}
+	if cfg.Storage.UploadMaxSize <= 0 {
+		errs = append(errs, errors.New("UPLOAD_MAX_SIZE must be positive"))
+	}
+	if cfg.Storage.ImportMaxSize <= 0 {
+		errs = append(errs, errors.New("IMPORT_MAX_SIZE must be positive"))
+	}
+	if cfg.Storage.TrashRetentionDays <= 0 {
+		errs = append(errs, errors.New("TRASH_RETENTION_DAYS must be positive"))
+	}
+	if cfg.Feed.ItemLimit <= 0 {
+		errs = append(errs, errors.New("FEED_ITEM_LIMIT must be positive"))
+	}
+	if cfg.Feed.SitemapPageSize <= 0 || cfg.Feed.SitemapPageSize > 50000 {
+		errs = append(errs, errors.New("SITEMAP_PAGE_SIZE must be between 1 and 50000"))
+	}
+
+	for _, setting := range []struct{ name, level string }{
+		{"LOG_LEVEL", cfg.Log.Level},
+		{"LOG_DB_LEVEL", cfg.Log.DBLevel},
+		{"LOG_REDIS_LEVEL", cfg.Log.RedisLevel},
+	} {
+		if _, err := logging.ParseLevel(setting.level); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", setting.name, err))
+		}
+	}
+	if cfg.Log.Format != logging.FormatJSON && cfg.Log.Format != logging.FormatText {
+		errs = append(errs, fmt.Errorf("unknown LOG_FORMAT %q, use json or text", cfg.Log.Format))
+	}
+	if cfg.Log.SlowQuery < 0 {
+		errs = append(errs, errors.New("LOG_SLOW_QUERY can't be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted is a copy safe to print, secrets that are set are masked.
+func (cfg *Config) Redacted() *Config {
+	copied := *cfg
+	copied.DB.ReplicaDSNs = append([]string(nil), cfg.DB.ReplicaDSNs...)
+	redact(reflect.ValueOf(&copied).Elem())
+	return &copied
+}
+
+func redact(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Duration(0)):
+			redact(field)
+		case value.Type().Field(i).Tag.Get("secret") != "true":
+		case field.Kind() == reflect.String && field.String() != "":
+			field.SetString(redacted)
+		case field.Kind() == reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				field.Index(j).SetString(redacted)
+			}
+		}
+	}
+}
+
+// Print writes the redacted configuration as YAML, durations included in
+// their readable form.
+func (cfg *Config) Print(w io.Writer) error {
+	var node yaml.Node
+	if err := node.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	readableDurations(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+	return encoder.Encode(&node)
+}
+
+// readableDurations rewrites durations, which yaml encodes as nanoseconds,
+// as "30m0s" so the output can be pasted back into a config file.
+func readableDurations(node *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i++ {
+		key, value := node.Content[i], node.Content[i+1]
+		if node.Kind == yaml.MappingNode && value.Kind == yaml.ScalarNode && durationKeys[key.Value] {
+			if nanoseconds, err := strconv.ParseInt(value.Value, 10, 64); err == nil {
+				value.Value = time.Duration(nanoseconds).String()
+				value.Tag = "!!str"
+			}
+		}
+	}
+	for _, child := range node.Content {
+		readableDurations(child)
+	}
+}
+
+// durationKeys are the yaml keys of every duration setting.
+var durationKeys = durationFields(reflect.TypeOf(Config{}), map[string]bool{})
+
+func durationFields(t reflect.Type, keys map[string]bool) map[string]bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			keys[field.Tag.Get("yaml")] = true
+		case field.Type.Kind() == reflect.Struct:
+			durationFields(field.Type, keys)
+		}
+	}
+	return keys
+}
+
+// InitConfig loads and validates the configuration, refusing to start with
+// settings that would run the API insecurely or not at all.
+func InitConfig() *Config {
+	cfg, err := Load()
+	if err != nil {
+		panic("Failed to load config because " + err.Error())
+	}
+	if err := cfg.Validate(); err != nil {
+		panic("Invalid config because " + strings.ReplaceAll(err.Error(), "\n", ", "))
+	}
+	return cfg
+}
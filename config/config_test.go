@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "Vx3nQ8pL2mZr7TkW9sYb4HcJ6dFg1aEu"
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(file, []byte("app_url: https://file.example.com/\ndb:\n  driver: postgresql\n  max_open_conns: 50\n  conn_max_lifetime: 1h\nfeed:\n  title: From file\n"), 0o644)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("FEED_TITLE", "From env")
+	t.Setenv("DB_REPLICA_DSNS", "replica-a, replica-b")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://file.example.com", cfg.AppURL)
+	assert.Equal(t, DriverPostgres, cfg.DB.Driver)
+	assert.Equal(t, 50, cfg.DB.MaxOpenConns)
+	assert.Equal(t, time.Hour, cfg.DB.ConnMaxLifetime)
+	assert.Equal(t, "From env", cfg.Feed.Title)
+	assert.Equal(t, []string{"replica-a", "replica-b"}, cfg.DB.ReplicaDSNs)
+	assert.Equal(t, 10, cfg.DB.MaxIdleConns)
+}
+
+func TestLoadRejectsMalformedValues(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	_, err := Load()
+	assert.EqualError(t, err, "DB_MAX_OPEN_CONNS must be a number")
+}
+
+func TestValidateSecrets(t *testing.T) {
+	cfg := Defaults()
+	assert.ErrorContains(t, cfg.Validate(), "JWT_SECRET is required")
+
+	cfg.Auth.JWTSecret = "secret"
+	assert.ErrorContains(t, cfg.Validate(), "JWT_SECRET is too weak")
+
+	cfg.Auth.JWTSecret = strings.Repeat("ab", 20)
+	assert.ErrorContains(t, cfg.Validate(), "JWT_SECRET is too weak")
+
+	cfg.Auth.JWTSecret = testSecret
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateLogging(t *testing.T) {
+	cfg := Defaults()
+	cfg.Auth.JWTSecret = testSecret
+	cfg.Log.DBLevel = "verbose"
+	cfg.Log.Format = "xml"
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "LOG_DB_LEVEL")
+	assert.ErrorContains(t, err, "LOG_FORMAT")
+
+	cfg.Log.DBLevel = "off"
+	cfg.Log.Format = "text"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := Defaults()
+	cfg.Auth.JWTSecret = testSecret
+	cfg.DB.ReplicaDSNs = []string{"user:hunter2@tcp(replica)/go-api"}
+
+	var out strings.Builder
+	assert.NoError(t, cfg.Print(&out))
+	assert.NotContains(t, out.String(), testSecret)
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.Contains(t, out.String(), "conn_max_lifetime: 30m0s")
+	assert.Equal(t, testSecret, cfg.Auth.JWTSecret)
+	assert.Equal(t, "user:hunter2@tcp(replica)/go-api", cfg.DB.ReplicaDSNs[0])
+}
@@ -1,37 +1,174 @@
 package config
 
 import (
+	"database/sql"
 	"fmt"
-	"os"
+	"log/slog"
+	"strings"
+	"time"
 
-	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/migrations"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+const maxConnectBackoff = 30 * time.Second
 
-func InitDB() {
-	var err error
-	username := os.Getenv("DB_USERNAME")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	dbname := os.Getenv("DB_NAME")
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// isMemorySQLite tells whether dsn is an in memory SQLite database, which
+// only lives as long as its one connection.
+func isMemorySQLite(dsn string) bool {
+	return strings.HasPrefix(dsn, "file::memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// dialector picks the driver of DB_DRIVER and connects either with DB_DSN
+// as is or with a DSN built from the DB_USERNAME, DB_HOST, ... fields.
+func dialector(cfg *Config) (gorm.Dialector, string, error) {
+	db := cfg.DB
+	dsn := db.DSN
+	if dsn == "" {
+		switch db.Driver {
+		case DriverMySQL:
+			dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+				db.Username, db.Password, db.Host, db.Port, db.Name)
+		case DriverPostgres:
+			dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+				db.Host, db.Port, db.Username, db.Password, db.Name, db.SSLMode)
+		case DriverSQLite:
+			dsn = db.Name
+		}
+	}
+	return dialectorFor(db.Driver, dsn)
+}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		username, password, host, port, dbname)
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// dialectorFor opens dsn with driver, the replicas go through it too.
+func dialectorFor(driver, dsn string) (gorm.Dialector, string, error) {
+	switch driver {
+	case DriverMySQL:
+		return mysql.Open(dsn), dsn, nil
+	case DriverPostgres:
+		return postgres.Open(dsn), dsn, nil
+	case DriverSQLite:
+		if dsn == "" {
+			dsn = "go-api.db"
+		}
+		if dsn == ":memory:" {
+			dsn = "file::memory:"
+		}
+		// SQLite leaves foreign keys off unless asked, the cascades on
+		// articles, attachments, ... rely on them.
+		if !strings.Contains(dsn, "_foreign_keys") && !strings.Contains(dsn, "_fk") {
+			if strings.Contains(dsn, "?") {
+				dsn += "&_foreign_keys=on"
+			} else {
+				dsn += "?_foreign_keys=on"
+			}
+		}
+		return sqlite.Open(dsn), dsn, nil
+	default:
+		return nil, "", fmt.Errorf("unknown DB_DRIVER %q, use mysql, postgres or sqlite", driver)
+	}
+}
 
+// ConnectDB connects to the primary database, it does not migrate. Queries
+// are logged to logger at LOG_DB_LEVEL.
+func ConnectDB(cfg *Config, logger *slog.Logger) (*gorm.DB, error) {
+	dialect, dsn, err := dialector(cfg)
 	if err != nil {
-		panic("Failed to connect")
+		return nil, err
 	}
-	// defer db.DB()
 
-	DB.AutoMigrate(&models.User{}, &models.Article{})
+	db, err := openWithRetry(cfg, dialect, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	configurePool(cfg, sqlDB, dsn)
+	return db, nil
 }
 
-func MigrateFreshDB() {
-	DB.Migrator().DropTable(&models.User{}, &models.Article{})
-	DB.AutoMigrate(&models.User{}, &models.Article{})
+// configurePool sizes a pool from the DB_MAX_OPEN_CONNS, ... settings, the
+// primary and every replica get the same.
+func configurePool(cfg *Config, sqlDB *sql.DB, dsn string) {
+	sqlDB.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
+
+	if cfg.DB.Driver == DriverSQLite && isMemorySQLite(dsn) {
+		// Every new connection would open an empty database of its own,
+		// and one closed for being idle or old takes the data with it.
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+}
+
+// openWithRetry keeps trying to connect for DB_CONNECT_RETRIES more times,
+// doubling the wait from DB_CONNECT_BACKOFF each time, so the API survives
+// starting next to a database that is still booting.
+func openWithRetry(cfg *Config, dialect gorm.Dialector, logger *slog.Logger) (*gorm.DB, error) {
+	retries := cfg.DB.ConnectRetries
+	backoff := cfg.DB.ConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(dialect, &gorm.Config{
+			TranslateError: true,
+			Logger:         cfg.Log.gormLogger(logger),
+		})
+		if err == nil {
+			return db, nil
+		}
+		// A failed ping still leaves the pool open.
+		if db != nil {
+			if sqlDB, errs := db.DB(); errs == nil {
+				sqlDB.Close()
+			}
+		}
+		if attempt > retries {
+			return nil, err
+		}
+
+		logger.Warn("Failed to connect to the database, retrying",
+			"attempt", attempt, "attempts", retries+1, "retry_in", backoff.String(), "error", err.Error())
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+// CloseDB closes a connection pool, waiting for running queries.
+func CloseDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func MigrateDB(db *gorm.DB, driver string) error {
+	_, err := migrations.Up(db, driver, 0)
+	return err
+}
+
+// ResetDB rolls back every migration and applies them again, leaving an
+// empty database.
+func ResetDB(db *gorm.DB, driver string) error {
+	if _, err := migrations.Down(db, driver, 0); err != nil {
+		return err
+	}
+	return MigrateDB(db, driver)
 }
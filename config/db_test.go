@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// loadConfig loads the configuration from the env the test has set.
+func loadConfig(t *testing.T) *Config {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestConnectDBMemorySQLite(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_DSN", ":memory:")
+	cfg := loadConfig(t)
+	db, err := ConnectDB(cfg, cfg.Log.NewLogger(io.Discard))
+	assert.NoError(t, err)
+	defer CloseDB(db)
+	assert.NoError(t, MigrateDB(db, cfg.DB.Driver))
+
+	assert.NoError(t, db.Create(&models.User{Username: "Rena", Email: "rena.aliana@yahoo.com"}).Error)
+
+	// The in memory database has to survive across queries, it only does
+	// on a single connection.
+	var user models.User
+	assert.NoError(t, db.First(&user, "LOWER(email) = LOWER(?)", "Rena.Aliana@yahoo.com").Error)
+	assert.Equal(t, "Rena", user.Username)
+}
+
+func TestDialectorUnknownDriver(t *testing.T) {
+	t.Setenv("DB_DRIVER", "oracle")
+	_, _, err := dialector(loadConfig(t))
+	assert.Error(t, err)
+}
+
+func TestReaderFallsBackToPrimary(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_DSN", ":memory:")
+	t.Setenv("DB_REPLICA_DSNS", t.TempDir()+"/replica.db")
+	cfg := loadConfig(t)
+	db, err := ConnectDB(cfg, cfg.Log.NewLogger(io.Discard))
+	assert.NoError(t, err)
+	defer CloseDB(db)
+	replicas, err := ConnectReplicas(cfg, db, nil, cfg.Log.NewLogger(io.Discard))
+	assert.NoError(t, err)
+	defer replicas.Close()
+
+	assert.Equal(t, 1, replicas.Healthy())
+	assert.NotSame(t, db, replicas.Reader(0))
+
+	replicas.replicas[0].healthy.Store(false)
+	assert.Same(t, db, replicas.Reader(0))
+}
+
+func TestReaderAfterWriteUsesPrimary(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_DSN", ":memory:")
+	t.Setenv("DB_REPLICA_DSNS", t.TempDir()+"/replica.db")
+	t.Setenv("DB_REPLICA_STICKINESS", "1m")
+	cfg := loadConfig(t)
+	db, err := ConnectDB(cfg, cfg.Log.NewLogger(io.Discard))
+	assert.NoError(t, err)
+	defer CloseDB(db)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	replicas, err := ConnectReplicas(cfg, db, rdb, cfg.Log.NewLogger(io.Discard))
+	assert.NoError(t, err)
+	defer replicas.Close()
+
+	replicas.StickToPrimary(7)
+	assert.Same(t, db, replicas.Reader(7))
+	assert.NotSame(t, db, replicas.Reader(8))
+	assert.NotSame(t, db, replicas.Reader(0))
+
+	mr.FastForward(time.Minute)
+	assert.NotSame(t, db, replicas.Reader(7))
+}
+
+func TestReplicasClose(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_DSN", ":memory:")
+	t.Setenv("DB_REPLICA_DSNS", t.TempDir()+"/replica.db")
+	cfg := loadConfig(t)
+	db, err := ConnectDB(cfg, cfg.Log.NewLogger(io.Discard))
+	assert.NoError(t, err)
+	defer CloseDB(db)
+	replicas, err := ConnectReplicas(cfg, db, nil, cfg.Log.NewLogger(io.Discard))
+	assert.NoError(t, err)
+
+	checked := make(chan struct{})
+	go func() {
+		defer close(checked)
+		for i := 0; i < 100; i++ {
+			replicas.Check()
+		}
+	}()
+	replicas.Close()
+	<-checked
+
+	assert.Equal(t, 0, replicas.Healthy())
+	assert.Same(t, db, replicas.Reader(0))
+}
+
+// flakyDialector fails the first connections, like a database still
+// booting.
+type flakyDialector struct {
+	gorm.Dialector
+	failures, attempts int
+}
+
+func (d *flakyDialector) Initialize(db *gorm.DB) error {
+	if d.attempts++; d.attempts <= d.failures {
+		return errors.New("connection refused")
+	}
+	return d.Dialector.Initialize(db)
+}
+
+func TestOpenWithRetry(t *testing.T) {
+	t.Setenv("DB_CONNECT_RETRIES", "2")
+	t.Setenv("DB_CONNECT_BACKOFF", "1ms")
+	cfg := loadConfig(t)
+	logger := cfg.Log.NewLogger(io.Discard)
+
+	dialect := &flakyDialector{Dialector: sqlite.Open(":memory:"), failures: 2}
+	db, err := openWithRetry(cfg, dialect, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, dialect.attempts)
+	CloseDB(db)
+
+	dialect = &flakyDialector{Dialector: sqlite.Open(":memory:"), failures: 3}
+	start := time.Now()
+	_, err = openWithRetry(cfg, dialect, logger)
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 3, dialect.attempts)
+	// Waited 1ms, then 2ms.
+	assert.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond)
+}
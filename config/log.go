@@ -0,0 +1,34 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/ArdhanaGusti/Golang_api/logging"
+	"github.com/go-redis/redis"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// level reads a validated level, info if it isn't.
+func level(name string) slog.Level {
+	level, err := logging.ParseLevel(name)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// NewLogger writes the records of LOG_LEVEL and up to w in LOG_FORMAT.
+func (cfg LogConfig) NewLogger(w io.Writer) *slog.Logger {
+	return logging.New(w, cfg.Format, level(cfg.Level))
+}
+
+// gormLogger logs the queries of LOG_DB_LEVEL and up.
+func (cfg LogConfig) gormLogger(logger *slog.Logger) gormlogger.Interface {
+	return logging.GORM(logger, level(cfg.DBLevel), cfg.SlowQuery)
+}
+
+// hookRedis logs the commands of LOG_REDIS_LEVEL and up.
+func (cfg LogConfig) hookRedis(rdb *redis.Client, logger *slog.Logger) {
+	logging.HookRedis(rdb, logger, level(cfg.RedisLevel), cfg.SlowQuery)
+}
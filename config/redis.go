@@ -1,23 +1,24 @@
 package config
 
 import (
-	"fmt"
-	"os"
+	"log/slog"
 
 	"github.com/go-redis/redis"
 )
 
-var RDB *redis.Client
-
-func InitRedis() {
-	RDB = redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_ADDRESS"),
-		Password: os.Getenv("REDIS_PASSWORD"),
+// ConnectRedis connects to REDIS_ADDRESS, commands are logged to logger at
+// LOG_REDIS_LEVEL.
+func ConnectRedis(cfg *Config, logger *slog.Logger) (*redis.Client, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
 		DB:       0,
 	})
-	err := RDB.Ping().Err()
-	if err != nil {
-		panic("Failed to connect redis because " + err.Error())
+	if err := rdb.Ping().Err(); err != nil {
+		rdb.Close()
+		return nil, err
 	}
-	fmt.Println("Connected to Redis!")
+	cfg.Log.hookRedis(rdb, logger)
+	logger.Info("Connected to Redis", "address", cfg.Redis.Address)
+	return rdb, nil
 }
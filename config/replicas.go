@@ -0,0 +1,163 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+)
+
+const replicaPingTimeout = 2 * time.Second
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// Replicas routes read only queries to the read replicas listed in
+// DB_REPLICA_DSNS, falling back to the primary.
+type Replicas struct {
+	primary    *gorm.DB
+	rdb        *redis.Client
+	logger     *slog.Logger
+	stickiness time.Duration
+	replicas   []*replica
+	next       atomic.Uint64
+	closed     atomic.Bool
+}
+
+// ConnectReplicas opens a pool for every replica. A replica that is down
+// does not stop the API from starting, reads go to the primary until the
+// health check sees it back.
+func ConnectReplicas(cfg *Config, primary *gorm.DB, rdb *redis.Client, logger *slog.Logger) (*Replicas, error) {
+	replicas := &Replicas{primary: primary, rdb: rdb, logger: logger, stickiness: cfg.DB.ReplicaStickiness}
+	for i, dsn := range cfg.DB.ReplicaDSNs {
+		// Replicas are named by position, their DSNs hold passwords.
+		name := "replica " + strconv.Itoa(i+1)
+		dialect, dsn, err := dialectorFor(cfg.DB.Driver, dsn)
+		if err != nil {
+			replicas.Close()
+			return nil, err
+		}
+		db, err := gorm.Open(dialect, &gorm.Config{
+			TranslateError:       true,
+			DisableAutomaticPing: true,
+			Logger:               cfg.Log.gormLogger(logger.With("database", name)),
+		})
+		if err != nil {
+			replicas.Close()
+			return nil, err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			replicas.Close()
+			return nil, err
+		}
+		configurePool(cfg, sqlDB, dsn)
+		replicas.replicas = append(replicas.replicas, &replica{name: name, db: db})
+	}
+	replicas.Check()
+	return replicas, nil
+}
+
+// Check pings every replica and only keeps the ones answering in the
+// rotation.
+func (replicas *Replicas) Check() {
+	for _, r := range replicas.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+		err := pingReplica(ctx, r)
+		cancel()
+		if replicas.closed.Load() {
+			return
+		}
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				replicas.logger.Info("Database is healthy", "database", r.name)
+			} else {
+				replicas.logger.Warn("Database is unhealthy, reading from the primary instead",
+					"database", r.name, "error", err.Error())
+			}
+		}
+	}
+}
+
+func pingReplica(ctx context.Context, r *replica) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Len counts the configured replicas.
+func (replicas *Replicas) Len() int {
+	return len(replicas.replicas)
+}
+
+// Healthy counts the replicas reads currently go to.
+func (replicas *Replicas) Healthy() int {
+	healthy := 0
+	for _, r := range replicas.replicas {
+		if r.healthy.Load() {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// Close takes every replica out of the rotation and closes its pool. The
+// list itself stays as it is, a health check running at the same time
+// reads it safely and stops instead of reporting the closed pools down.
+func (replicas *Replicas) Close() {
+	replicas.closed.Store(true)
+	for _, r := range replicas.replicas {
+		r.healthy.Store(false)
+		CloseDB(r.db)
+	}
+}
+
+func stickyKey(userID uint) string {
+	return "primary:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// StickToPrimary sends the reads of a user who just wrote to the primary
+// for DB_REPLICA_STICKINESS, long enough for the replicas to catch up, so
+// they see their own changes.
+func (replicas *Replicas) StickToPrimary(userID uint) {
+	if len(replicas.replicas) == 0 || userID == 0 {
+		return
+	}
+	replicas.rdb.Set(stickyKey(userID), 1, replicas.stickiness)
+}
+
+// Reader is the database a read only query of userID goes to, 0 for
+// anonymous readers. Replicas take turns, the primary answers when there is
+// no healthy replica or the user wrote lately.
+func (replicas *Replicas) Reader(userID uint) *gorm.DB {
+	if len(replicas.replicas) == 0 {
+		return replicas.primary
+	}
+	if userID != 0 {
+		// Without Redis there is no telling, the primary is always right.
+		sticky, err := replicas.rdb.Exists(stickyKey(userID)).Result()
+		if err != nil || sticky > 0 {
+			return replicas.primary
+		}
+	}
+
+	start := replicas.next.Add(1)
+	for i := range replicas.replicas {
+		r := replicas.replicas[(start+uint64(i))%uint64(len(replicas.replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return replicas.primary
+}
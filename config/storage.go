@@ -0,0 +1,9 @@
+package config
+
+import (
+	"github.com/ArdhanaGusti/Golang_api/storage"
+)
+
+func OpenStorage(cfg *Config) (storage.BlobStore, error) {
+	return storage.NewLocalStore(cfg.Storage.Path)
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"strings"
+)
+
+// ArticleURL is the public link of an article. ARTICLE_URL lets a frontend
+// take over article pages, otherwise the API endpoint is used.
+func (cfg *Config) ArticleURL(slug string) string {
+	base := cfg.ArticleBaseURL
+	if base == "" {
+		base = cfg.AppURL + "/api/v1/article/"
+	}
+	return strings.TrimSuffix(base, "/") + "/" + slug
+}
@@ -0,0 +1,86 @@
+package conditional
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ArticleETag changes every time the article row is written, it is built
+// from the version column that updates bump atomically.
+func ArticleETag(article models.Article) string {
+	return `"` + strconv.FormatUint(uint64(article.ID), 10) + "-" + strconv.FormatUint(uint64(article.Version), 10) + `"`
+}
+
+// ArticleFormatETag is the ETag of one representation of an article. The
+// markdown one, the default, is ArticleETag itself so it can be sent back
+// in If-Match, the others carry their format: a cache holding the markdown
+// body must not revalidate it for the html URL.
+func ArticleFormatETag(article models.Article, format string) string {
+	etag := ArticleETag(article)
+	if format == markdown.FormatMarkdown {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + format + `"`
+}
+
+// MatchStrong reports whether an If-Match header value lists etag. If-Match
+// guards writes, so as RFC 9110 wants a weak validator never matches.
+func MatchStrong(header, etag string) bool {
+	if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether an If-None-Match header value lists etag. Weak
+// validators compare equal to their strong form.
+func Match(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModified writes the ETag and Last-Modified validators and reports
+// whether the client copy is still fresh, in which case a 304 has already
+// been sent. If-None-Match wins over If-Modified-Since as RFC 9110 says.
+func NotModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	fresh := false
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		fresh = Match(ifNoneMatch, etag)
+	} else if ifModifiedSince := c.GetHeader("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		fresh = err == nil && !lastModified.After(since)
+	}
+
+	if fresh {
+		c.Status(http.StatusNotModified)
+		c.Abort()
+	}
+	return fresh
+}
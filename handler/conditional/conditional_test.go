@@ -0,0 +1,75 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestArticleFormatETag(t *testing.T) {
+	article := models.Article{Model: gorm.Model{ID: 7}, Version: 3}
+	assert.Equal(t, `"7-3"`, ArticleFormatETag(article, markdown.FormatMarkdown))
+	assert.Equal(t, ArticleETag(article), ArticleFormatETag(article, markdown.FormatMarkdown))
+	assert.Equal(t, `"7-3-html"`, ArticleFormatETag(article, markdown.FormatHTML))
+	assert.Equal(t, `"7-3-text"`, ArticleFormatETag(article, markdown.FormatText))
+}
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct {
+		header, etag string
+		weak, strong bool
+	}{
+		{`"7-3"`, `"7-3"`, true, true},
+		{`"1-1", "7-3"`, `"7-3"`, true, true},
+		{`*`, `"7-3"`, true, true},
+		{`W/"7-3"`, `"7-3"`, true, false},
+		{`"7-3"`, `W/"7-3"`, true, false},
+		{`"7-2"`, `"7-3"`, false, false},
+	} {
+		assert.Equal(t, tt.weak, Match(tt.header, tt.etag), "Match(%s, %s)", tt.header, tt.etag)
+		assert.Equal(t, tt.strong, MatchStrong(tt.header, tt.etag), "MatchStrong(%s, %s)", tt.header, tt.etag)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name    string
+		method  string
+		headers map[string]string
+		fresh   bool
+	}{
+		{"no validators", http.MethodGet, nil, false},
+		{"etag matches", http.MethodGet, map[string]string{"If-None-Match": `W/"7-3"`}, true},
+		{"etag changed", http.MethodGet, map[string]string{"If-None-Match": `"7-2"`}, false},
+		{"not modified since", http.MethodGet, map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", http.MethodGet, map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, false},
+		{"etag wins over date", http.MethodGet, map[string]string{"If-None-Match": `"7-2"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, false},
+		{"head", http.MethodHead, map[string]string{"If-None-Match": `"7-3"`}, true},
+		{"writes always run", http.MethodPut, map[string]string{"If-None-Match": `"7-3"`}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/", nil)
+			for key, value := range tt.headers {
+				c.Request.Header.Set(key, value)
+			}
+
+			assert.Equal(t, tt.fresh, NotModified(c, `"7-3"`, modified.Add(500*time.Millisecond)))
+			assert.Equal(t, `"7-3"`, w.Header().Get("ETag"))
+			assert.Equal(t, modified.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+			if tt.fresh {
+				c.Writer.WriteHeaderNow()
+				assert.Equal(t, http.StatusNotModified, w.Code)
+			}
+		})
+	}
+}
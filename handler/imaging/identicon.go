@@ -0,0 +1,36 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const identiconGrid = 5
+
+// Identicon draws a GitHub style 5x5 horizontally mirrored pattern. The
+// same seed always gives the same picture.
+func Identicon(seed string, size int) image.Image {
+	sum := sha256.Sum256([]byte(seed))
+	foreground := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}
+	background := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	cell := size / identiconGrid
+	offset := (size - cell*identiconGrid) / 2
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < (identiconGrid+1)/2; col++ {
+			if sum[3+row*3+col]%2 == 0 {
+				continue
+			}
+			for _, c := range []int{col, identiconGrid - 1 - col} {
+				rect := image.Rect(offset+c*cell, offset+row*cell, offset+(c+1)*cell, offset+(row+1)*cell)
+				draw.Draw(img, rect, &image.Uniform{C: foreground}, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,34 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pngClaiming encodes a 1x1 PNG, then rewrites its header to claim
+// width x height, the way a decompression bomb does.
+func pngClaiming(t *testing.T, width, height uint32) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+	data := buf.Bytes()
+	// Signature (8), IHDR length (4) and type (4), then width and height.
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestDecodeRefusesTooManyPixels(t *testing.T) {
+	_, err := Decode(pngClaiming(t, 50000, 50000))
+	assert.ErrorIs(t, err, ErrTooManyPixels)
+
+	img, err := Decode(pngClaiming(t, 1, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, img.Bounds().Dx())
+}
@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"errors"
+	"log/slog"
+	"net/smtp"
+	"strings"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+)
+
+var ErrNotConfigured = errors.New("SMTP_HOST is not set")
+
+// Send delivers a plain text email through SMTP_HOST. Without an SMTP
+// server it fails, unless SMTP_LOG_MESSAGES is on: local development then
+// gets the message, verification link included, in the log.
+func Send(logger *slog.Logger, settings config.MailConfig, to, subject, body string) error {
+	host := settings.Host
+	if host == "" {
+		if !settings.LogMessages {
+			return ErrNotConfigured
+		}
+		logger.Info("SMTP_HOST is not set, mail not sent", "to", to, "subject", subject, "body", body)
+		return nil
+	}
+
+	port := settings.Port
+	from := settings.From
+
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + subject,
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	return smtp.SendMail(host+":"+port, auth, from, []string{to}, []byte(message))
+}
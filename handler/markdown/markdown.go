@@ -0,0 +1,79 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatText     = "text"
+)
+
+var renderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+// policy only keeps the tags a rendered article actually needs, everything
+// else (script, iframe, inline event handlers, ...) is stripped.
+var policy = newPolicy()
+
+var textPolicy = bluemonday.StrictPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowStandardURLs()
+	p.AllowElements(
+		"p", "br", "hr", "blockquote", "pre", "code",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+		"strong", "em", "del", "ul", "ol", "li",
+		"table", "thead", "tbody", "tr", "th", "td",
+	)
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("src", "alt", "title").OnElements("img")
+	p.AllowAttrs("align").OnElements("th", "td")
+	p.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).OnElements("code")
+	p.RequireNoFollowOnLinks(true)
+	return p
+}
+
+// Render converts markdown into sanitized HTML.
+func Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
+
+// PlainText strips every tag from rendered HTML.
+func PlainText(renderedHTML string) string {
+	return strings.TrimSpace(html.UnescapeString(textPolicy.Sanitize(renderedHTML)))
+}
+
+func IsValidFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatHTML || format == FormatText
+}
+
+// Excerpt shortens the plain text of rendered HTML to at most maxLength
+// runes, cutting at a word boundary.
+func Excerpt(renderedHTML string, maxLength int) string {
+	text := strings.Join(strings.Fields(PlainText(renderedHTML)), " ")
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+
+	cut := string(runes[:maxLength])
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,86 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		name       string
+		source     string
+		contains   []string
+		notContain []string
+	}{
+		{
+			name:     "formatting",
+			source:   "# Title\n\n**bold** and `code`",
+			contains: []string{"<h1>Title</h1>", "<strong>bold</strong>", "<code>code</code>"},
+		},
+		{
+			name:       "raw script",
+			source:     "hello <script>alert(1)</script>",
+			contains:   []string{"hello"},
+			notContain: []string{"<script", "alert(1)</script>"},
+		},
+		{
+			name:       "inline event handler",
+			source:     `<img src="x.png" onerror="alert(1)">`,
+			notContain: []string{"onerror"},
+		},
+		{
+			name:       "javascript link",
+			source:     "[click](javascript:alert(1))",
+			notContain: []string{"javascript:"},
+		},
+		{
+			name:     "links get nofollow",
+			source:   "[site](https://example.com)",
+			contains: []string{`href="https://example.com"`, `rel="nofollow"`},
+		},
+		{
+			name:     "image",
+			source:   `![tupai](https://example.com/tupai.png "Tupai")`,
+			contains: []string{`src="https://example.com/tupai.png"`, `alt="tupai"`, `title="Tupai"`},
+		},
+		{
+			name:     "code language class",
+			source:   "```go\nfmt.Println()\n```",
+			contains: []string{`<code class="language-go">`},
+		},
+		{
+			name:     "table",
+			source:   "| a | b |\n|---|---|\n| 1 | 2 |",
+			contains: []string{"<table>", "<th>a</th>", "<td>2</td>"},
+		},
+		{
+			name:       "iframe",
+			source:     `<iframe src="https://example.com"></iframe>`,
+			notContain: []string{"<iframe"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			html, err := Render(tc.source)
+			assert.NoError(t, err)
+			for _, want := range tc.contains {
+				assert.Contains(t, html, want)
+			}
+			for _, unwanted := range tc.notContain {
+				assert.NotContains(t, html, unwanted)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	assert.Equal(t, "Tom & Jerry", PlainText("<p><strong>Tom</strong> &amp; Jerry</p>"))
+}
+
+func TestExcerpt(t *testing.T) {
+	html := "<p>Tupai itu terbang ke langit, lalu pulang.</p>"
+	assert.Equal(t, "Tupai itu terbang ke langit, lalu pulang.", Excerpt(html, 100))
+	assert.Equal(t, "Tupai itu terbang ke langit…", Excerpt(html, 30))
+}
@@ -0,0 +1,125 @@
+package response
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/models"
+)
+
+type AttachmentResponse struct {
+	ID           uint   `json:"ID"`
+	FileName     string `json:"FileName"`
+	ContentType  string `json:"ContentType"`
+	Size         int64  `json:"Size"`
+	Width        int    `json:"Width,omitempty"`
+	Height       int    `json:"Height,omitempty"`
+	URL          string `json:"URL"`
+	ThumbnailURL string `json:"ThumbnailURL,omitempty"`
+}
+
+const (
+	excerptLength         = 280
+	metaDescriptionLength = 160
+)
+
+type SEOResponse struct {
+	MetaDescription string `json:"MetaDescription"`
+	CanonicalURL    string `json:"CanonicalURL"`
+	OGImage         string `json:"OGImage,omitempty"`
+}
+
+type ArticleResponse struct {
+	ID          uint                 `json:"ID"`
+	Title       string               `json:"Title"`
+	Tag         string               `json:"Tag"`
+	Slug        string               `json:"Slug"`
+	Desc        string               `json:"Desc"`
+	DescHTML    string               `json:"DescHTML"`
+	Excerpt     string               `json:"Excerpt"`
+	SEO         SEOResponse          `json:"SEO"`
+	Version     uint                 `json:"Version"`
+	ArchivedAt  *time.Time           `json:"ArchivedAt,omitempty"`
+	CreatedAt   time.Time            `json:"CreatedAt"`
+	UpdatedAt   time.Time            `json:"UpdatedAt"`
+	User        *PublicUserResponse  `json:"User,omitempty"`
+	Attachments []AttachmentResponse `json:"Attachments"`
+}
+
+func NewAttachment(attachment models.Attachment) AttachmentResponse {
+	url := "/api/v1/attachments/" + strconv.FormatUint(uint64(attachment.ID), 10)
+	result := AttachmentResponse{
+		ID:          attachment.ID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Width:       attachment.Width,
+		Height:      attachment.Height,
+		URL:         url,
+	}
+	if attachment.ThumbnailKey != "" {
+		result.ThumbnailURL = url + "?size=thumb"
+	}
+	return result
+}
+
+func NewArticle(cfg *config.Config, article models.Article) ArticleResponse {
+	result := ArticleResponse{
+		ID:       article.ID,
+		Title:    article.Title,
+		Tag:      article.Tag,
+		Slug:     article.Slug,
+		Desc:     article.Desc,
+		DescHTML: article.DescHTML,
+		Excerpt:  article.Excerpt,
+		SEO: SEOResponse{
+			MetaDescription: article.MetaDescription,
+			CanonicalURL:    article.CanonicalURL,
+			OGImage:         article.OGImage,
+		},
+		Version:     article.Version,
+		ArchivedAt:  article.ArchivedAt,
+		CreatedAt:   article.CreatedAt,
+		UpdatedAt:   article.UpdatedAt,
+		Attachments: make([]AttachmentResponse, 0, len(article.Attachments)),
+	}
+	// Only set when the author was preloaded, a zero User means it wasn't.
+	if article.User.ID != 0 {
+		author := NewPublicUser(article.User)
+		result.User = &author
+	}
+	for _, attachment := range article.Attachments {
+		result.Attachments = append(result.Attachments, NewAttachment(attachment))
+	}
+
+	// Authors may set every SEO field by hand, whatever is left empty is
+	// derived from the article itself.
+	if result.Excerpt == "" {
+		result.Excerpt = markdown.Excerpt(article.DescHTML, excerptLength)
+	}
+	if result.SEO.MetaDescription == "" {
+		result.SEO.MetaDescription = markdown.Excerpt(article.DescHTML, metaDescriptionLength)
+	}
+	if result.SEO.CanonicalURL == "" {
+		result.SEO.CanonicalURL = cfg.ArticleURL(article.Slug)
+	}
+	if result.SEO.OGImage == "" {
+		for _, attachment := range result.Attachments {
+			if attachment.Width > 0 {
+				result.SEO.OGImage = cfg.AppURL + attachment.URL
+				break
+			}
+		}
+	}
+	return result
+}
+
+func NewArticles(cfg *config.Config, articles []models.Article) []ArticleResponse {
+	result := make([]ArticleResponse, 0, len(articles))
+	for _, article := range articles {
+		result = append(result, NewArticle(cfg, article))
+	}
+	return result
+}
@@ -0,0 +1,19 @@
+package response
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+type CheckResponse struct {
+	Status    string
+	LatencyMs float64
+	Error     string `json:",omitempty"`
+}
+
+// HealthResponse is the answer of the probes, Checks only comes with
+// readiness.
+type HealthResponse struct {
+	Status string
+	Checks map[string]CheckResponse `json:",omitempty"`
+}
@@ -0,0 +1,117 @@
+package response
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var sensitiveFields = []string{
+	"Password",
+	"SocialID",
+	"EmailVerifyToken",
+	"EmailVerifyExpiresAt",
+	"AvatarChecksum",
+	"Checksum",
+	"Key",
+	"ThumbnailKey",
+	"$2a$10$",
+	"secret-token",
+}
+
+func fullUser() models.User {
+	expiresAt := time.Now()
+	user := models.User{
+		Model:                gorm.Model{ID: 7, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+		Username:             "Rena",
+		Fullname:             "Rena Aliana",
+		Email:                "rena.aliana@yahoo.com",
+		PendingEmail:         "rena@example.com",
+		EmailVerifyToken:     "secret-token",
+		EmailVerifyExpiresAt: &expiresAt,
+		Password:             "$2a$10$abcdefghijklmnopqrstuv",
+		SocialID:             "1234567890",
+		Provider:             "github",
+		AvatarChecksum:       "deadbeef",
+		Role:                 true,
+	}
+	user.Articles = []models.Article{fullArticle(user)}
+	return user
+}
+
+func fullArticle(author models.User) models.Article {
+	return models.Article{
+		Model:  gorm.Model{ID: 3},
+		Title:  "Tupai terbang",
+		Slug:   "tupai-terbang",
+		UserID: author.ID,
+		User:   author,
+		Attachments: []models.Attachment{{
+			Model:        gorm.Model{ID: 9},
+			Checksum:     "deadbeef",
+			Key:          "attachments/deadbeef.png",
+			ThumbnailKey: "attachments/thumbs/deadbeef.jpg",
+		}},
+	}
+}
+
+func assertNoSensitiveField(t *testing.T, value interface{}) {
+	body, err := json.Marshal(value)
+	assert.NoError(t, err)
+
+	var keys []string
+	collectKeys(t, body, &keys)
+	for _, field := range sensitiveFields {
+		assert.NotContains(t, keys, field)
+		assert.False(t, strings.Contains(string(body), field), "%s leaked in %s", field, body)
+	}
+}
+
+func collectKeys(t *testing.T, body []byte, keys *[]string) {
+	var decoded interface{}
+	assert.NoError(t, json.Unmarshal(body, &decoded))
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch typed := v.(type) {
+		case map[string]interface{}:
+			for key, child := range typed {
+				*keys = append(*keys, key)
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range typed {
+				walk(child)
+			}
+		}
+	}
+	walk(decoded)
+}
+
+func TestUserResponsesHideSensitiveFields(t *testing.T) {
+	user := fullUser()
+
+	assertNoSensitiveField(t, NewPublicUser(user))
+	assertNoSensitiveField(t, NewSelfUser(config.Defaults(), user))
+	assertNoSensitiveField(t, NewAdminUser(config.Defaults(), user))
+	assertNoSensitiveField(t, NewAdminUsers(config.Defaults(), []models.User{user}))
+}
+
+func TestArticleResponsesHideSensitiveFields(t *testing.T) {
+	article := fullArticle(fullUser())
+
+	assertNoSensitiveField(t, NewArticle(config.Defaults(), article))
+	assertNoSensitiveField(t, NewArticles(config.Defaults(), []models.Article{article}))
+}
+
+func TestPublicAuthorHidesEmail(t *testing.T) {
+	body, err := json.Marshal(NewArticle(config.Defaults(), fullArticle(fullUser())))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "rena.aliana@yahoo.com")
+}
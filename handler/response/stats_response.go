@@ -0,0 +1,24 @@
+package response
+
+type DailyStatsResponse struct {
+	Day           string
+	Views         uint64
+	UniqueReaders uint64
+}
+
+type ReferrerStatsResponse struct {
+	Referrer string
+	Views    uint64
+}
+
+// ArticleStatsResponse sums up the reads of an article over a date range.
+// Unique readers are only known per day, DailyUniqueReadersSum adds them up
+// so someone reading on two days counts twice.
+type ArticleStatsResponse struct {
+	From                  string
+	To                    string
+	Views                 uint64
+	DailyUniqueReadersSum uint64
+	Days                  []DailyStatsResponse
+	Referrers             []ReferrerStatsResponse
+}
@@ -0,0 +1,83 @@
+package response
+
+import (
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/models"
+)
+
+// PublicUserResponse is what anyone may see about a user, e.g. as the
+// author of an article.
+type PublicUserResponse struct {
+	ID        uint   `json:"ID"`
+	Username  string `json:"Username"`
+	Fullname  string `json:"Fullname"`
+	AvatarURL string `json:"AvatarURL"`
+}
+
+// SelfUserResponse is returned to the user looking at their own profile.
+type SelfUserResponse struct {
+	PublicUserResponse
+	Email        string            `json:"Email"`
+	PendingEmail string            `json:"PendingEmail,omitempty"`
+	Provider     string            `json:"Provider"`
+	Role         string            `json:"Role"`
+	CreatedAt    time.Time         `json:"CreatedAt"`
+	UpdatedAt    time.Time         `json:"UpdatedAt"`
+	Articles     []ArticleResponse `json:"Articles,omitempty"`
+}
+
+// AdminUserResponse adds the bookkeeping fields an admin needs to manage
+// accounts. Secrets like the password hash are still left out.
+type AdminUserResponse struct {
+	SelfUserResponse
+	DeletedAt *time.Time `json:"DeletedAt"`
+}
+
+func roleName(admin bool) string {
+	if admin {
+		return "admin"
+	}
+	return "user"
+}
+
+func NewPublicUser(user models.User) PublicUserResponse {
+	return PublicUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Fullname:  user.Fullname,
+		AvatarURL: models.AvatarPath(user.ID),
+	}
+}
+
+func NewSelfUser(cfg *config.Config, user models.User) SelfUserResponse {
+	return SelfUserResponse{
+		PublicUserResponse: NewPublicUser(user),
+		Email:              user.Email,
+		PendingEmail:       user.PendingEmail,
+		Provider:           user.Provider,
+		Role:               roleName(user.Role),
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+		Articles:           NewArticles(cfg, user.Articles),
+	}
+}
+
+func NewAdminUser(cfg *config.Config, user models.User) AdminUserResponse {
+	result := AdminUserResponse{
+		SelfUserResponse: NewSelfUser(cfg, user),
+	}
+	if user.DeletedAt.Valid {
+		result.DeletedAt = &user.DeletedAt.Time
+	}
+	return result
+}
+
+func NewAdminUsers(cfg *config.Config, users []models.User) []AdminUserResponse {
+	result := make([]AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		result = append(result, NewAdminUser(cfg, user))
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+package slugs
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+const maxAttempts = 5
+
+// Allocate picks the first free slug out of title, title-2, title-3, ...
+// Slugs of trashed articles and old slugs of other articles count as taken
+// so restores and redirects keep working. articleID is the article being
+// re-slugged, 0 for a new one.
+func Allocate(db *gorm.DB, title string, articleID uint) (string, error) {
+	base := slug.Make(title)
+	if base == "" {
+		base = "article"
+	}
+
+	taken := map[string]bool{}
+	var used []string
+	if err := db.Unscoped().Model(&models.Article{}).
+		Where("id <> ? AND (slug = ? OR slug LIKE ?)", articleID, base, base+"-%").
+		Pluck("slug", &used).Error; err != nil {
+		return "", err
+	}
+	var history []string
+	if err := db.Model(&models.SlugHistory{}).
+		Where("article_id <> ? AND (slug = ? OR slug LIKE ?)", articleID, base, base+"-%").
+		Pluck("slug", &history).Error; err != nil {
+		return "", err
+	}
+	for _, s := range append(used, history...) {
+		taken[s] = true
+	}
+
+	for n := 1; ; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = base + "-" + strconv.Itoa(n)
+		}
+		if !taken[candidate] {
+			return candidate, nil
+		}
+	}
+}
+
+// Normalize turns a slug written by hand, e.g. in an import file, into
+// the shape Allocate produces. Nothing usable left gives "".
+func Normalize(s string) string {
+	return slug.Make(s)
+}
+
+// Redirects reports whether s is the old slug of an article, which no
+// other article may take.
+func Redirects(db *gorm.DB, s string) (bool, error) {
+	var count int64
+	err := db.Model(&models.SlugHistory{}).Where("slug = ?", s).Count(&count).Error
+	return count > 0, err
+}
+
+// Create inserts article under a freshly allocated slug. The unique index
+// on slug is the real guard, when a concurrent request wins the race for
+// the same slug we simply allocate again.
+func Create(db *gorm.DB, article *models.Article) error {
+	for attempt := 1; ; attempt++ {
+		s, err := Allocate(db, article.Title, 0)
+		if err != nil {
+			return err
+		}
+		article.Slug = s
+
+		err = db.Create(article).Error
+		if err == nil || !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == maxAttempts {
+			return err
+		}
+	}
+}
+
+// Reslug moves article to a slug derived from title and keeps the old one
+// in the slug history. It returns the slug the article ends up with.
+func Reslug(db *gorm.DB, article *models.Article, title string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		newSlug, err := Allocate(db, title, article.ID)
+		if err != nil {
+			return "", err
+		}
+		if newSlug == article.Slug {
+			return newSlug, nil
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Going back to a slug this article used before.
+			if err := tx.Where("article_id = ? AND slug = ?", article.ID, newSlug).Delete(&models.SlugHistory{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.SlugHistory{Slug: article.Slug, ArticleID: article.ID}).Error; err != nil {
+				return err
+			}
+			return tx.Model(article).Updates(map[string]interface{}{
+				"slug":    newSlug,
+				"version": gorm.Expr("version + 1"),
+			}).Error
+		})
+		if err == nil {
+			return newSlug, nil
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == maxAttempts {
+			return "", err
+		}
+	}
+}
+
+// Resolve finds the current slug of an article that used to be reachable
+// under oldSlug.
+func Resolve(db *gorm.DB, oldSlug string) (string, error) {
+	var history models.SlugHistory
+	if err := db.First(&history, "slug = ?", oldSlug).Error; err != nil {
+		return "", err
+	}
+
+	var article models.Article
+	if err := db.Select("slug").First(&article, history.ArticleID).Error; err != nil {
+		return "", err
+	}
+	return article.Slug, nil
+}
@@ -0,0 +1,98 @@
+package slugs
+
+import (
+	"testing"
+
+	"github.com/ArdhanaGusti/Golang_api/migrations"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openDB(t *testing.T) (*gorm.DB, models.User) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{
+		TranslateError:         true,
+		SkipDefaultTransaction: true,
+	})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	_, err = migrations.Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+
+	user := models.User{Username: "Rena", Email: "rena.aliana@yahoo.com"}
+	assert.NoError(t, db.Create(&user).Error)
+	return db, user
+}
+
+func TestAllocateSkipsTakenSlugs(t *testing.T) {
+	db, user := openDB(t)
+
+	live := models.Article{Title: "Tupai terbang", Slug: "tupai-terbang", UserID: user.ID}
+	trashed := models.Article{Title: "Tupai terbang", Slug: "tupai-terbang-2", UserID: user.ID}
+	assert.NoError(t, db.Create(&live).Error)
+	assert.NoError(t, db.Create(&trashed).Error)
+	assert.NoError(t, db.Delete(&trashed).Error)
+	assert.NoError(t, db.Create(&models.SlugHistory{Slug: "tupai-terbang-3", ArticleID: live.ID}).Error)
+
+	slug, err := Allocate(db, "Tupai terbang", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "tupai-terbang-4", slug)
+
+	// An article keeps its own slug and may go back to an old one.
+	slug, err = Allocate(db, "Tupai terbang", live.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "tupai-terbang", slug)
+}
+
+func TestCreateRetriesAfterCollision(t *testing.T) {
+	db, user := openDB(t)
+
+	// Another request takes the allocated slug right before our insert.
+	raced := false
+	assert.NoError(t, db.Callback().Create().Before("gorm:create").Register("test:race", func(tx *gorm.DB) {
+		article, ok := tx.Statement.Dest.(*models.Article)
+		if !ok || raced {
+			return
+		}
+		raced = true
+		tx.Session(&gorm.Session{NewDB: true}).Create(&models.Article{Title: article.Title, Slug: article.Slug, UserID: user.ID})
+	}))
+
+	article := models.Article{Title: "Tupai terbang", UserID: user.ID}
+	assert.NoError(t, Create(db, &article))
+	assert.True(t, raced)
+	assert.Equal(t, "tupai-terbang-2", article.Slug)
+}
+
+func TestReslugKeepsOldSlugResolvable(t *testing.T) {
+	db, user := openDB(t)
+
+	article := models.Article{Title: "Tupai terbang", UserID: user.ID}
+	assert.NoError(t, Create(db, &article))
+
+	slug, err := Reslug(db, &article, "Tupai berdiri")
+	assert.NoError(t, err)
+	assert.Equal(t, "tupai-berdiri", slug)
+
+	current, err := Resolve(db, "tupai-terbang")
+	assert.NoError(t, err)
+	assert.Equal(t, "tupai-berdiri", current)
+
+	// Nobody else gets the old slug while it redirects.
+	other, err := Allocate(db, "Tupai terbang", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "tupai-terbang-2", other)
+
+	// Going back drops the history entry of the slug in use again.
+	article.Slug = slug
+	slug, err = Reslug(db, &article, "Tupai terbang")
+	assert.NoError(t, err)
+	assert.Equal(t, "tupai-terbang", slug)
+	_, err = Resolve(db, "tupai-terbang")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	current, err = Resolve(db, "tupai-berdiri")
+	assert.NoError(t, err)
+	assert.Equal(t, "tupai-terbang", current)
+}
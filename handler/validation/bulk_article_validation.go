@@ -0,0 +1,12 @@
+package validation
+
+type BulkArticleOperation struct {
+	Op   string `json:"Op" binding:"required,oneof=retag archive unarchive delete"`
+	Slug string `json:"Slug" binding:"required"`
+	Tag  string `json:"Tag" binding:"required_if=Op retag"`
+}
+
+type BulkArticlePayload struct {
+	Atomic     bool                   `json:"Atomic"`
+	Operations []BulkArticleOperation `json:"Operations" binding:"required,min=1,max=500,dive"`
+}
@@ -0,0 +1,6 @@
+package validation
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"CurrentPassword" form:"CurrentPassword"`
+	NewPassword     string `json:"NewPassword" form:"NewPassword" binding:"required,min=8"`
+}
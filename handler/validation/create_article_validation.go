@@ -1,7 +1,11 @@
 package validation
 
 type CreateArticlePayload struct {
-	Title string `json:"Title" form:"Title" binding:"required"`
-	Desc  string `json:"Desc" form:"Desc" binding:"required"`
-	Tag   string `json:"Tag" form:"Tag" binding:"required"`
+	Title           string `json:"Title" form:"Title" binding:"required"`
+	Desc            string `json:"Desc" form:"Desc" binding:"required"`
+	Tag             string `json:"Tag" form:"Tag" binding:"required"`
+	Excerpt         string `json:"Excerpt,omitempty" form:"Excerpt" binding:"max=500"`
+	MetaDescription string `json:"MetaDescription,omitempty" form:"MetaDescription" binding:"max=320"`
+	CanonicalURL    string `json:"CanonicalURL,omitempty" form:"CanonicalURL" binding:"omitempty,url"`
+	OGImage         string `json:"OGImage,omitempty" form:"OGImage" binding:"omitempty,url"`
 }
@@ -0,0 +1,6 @@
+package validation
+
+type DeleteAccountPayload struct {
+	Password string `json:"Password" form:"Password"`
+	Mode     string `json:"Mode" form:"Mode" binding:"required,oneof=anonymize cascade"`
+}
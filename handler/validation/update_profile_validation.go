@@ -0,0 +1,7 @@
+package validation
+
+type UpdateProfilePayload struct {
+	Username *string `json:"Username" form:"Username" binding:"omitempty,min=1"`
+	Fullname *string `json:"Fullname" form:"Fullname" binding:"omitempty,min=1"`
+	Email    *string `json:"Email" form:"Email" binding:"omitempty,email"`
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// every calls job every interval, and once right away when now is set,
+// until ctx is done. A run in progress is never cut short, wg is done once
+// the last one returned so the connections it uses can be closed safely.
+func every(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, now bool, job func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		if now {
+			job()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job()
+			}
+		}
+	}()
+}
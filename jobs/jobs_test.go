@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/app"
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/migrations"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// testApp is an App on an in memory database, Redis and a temporary
+// storage directory, with one user to write articles as.
+func testApp(t *testing.T) (*app.App, models.User) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	_, err = migrations.Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	user := models.User{Username: "Rena", Email: "rena.aliana@yahoo.com"}
+	assert.NoError(t, db.Create(&user).Error)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	store, err := storage.NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	cfg := config.Defaults()
+	return &app.App{Config: cfg, DB: db, RDB: rdb, Storage: store, Logger: cfg.Log.NewLogger(io.Discard)}, user
+}
+
+func TestEveryWaitsForRunInProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	started := make(chan struct{})
+	var finished atomic.Bool
+	every(ctx, &wg, time.Hour, true, func() {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	<-started
+	cancel()
+	wg.Wait()
+	assert.True(t, finished.Load(), "shutdown must not cut a run short")
+}
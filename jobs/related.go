@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/app"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/recommend"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+)
+
+// RelatedIndex builds the similarity index over every published article.
+func RelatedIndex(db *gorm.DB) (*recommend.Index, error) {
+	var articles []models.Article
+	if err := db.Select("id", "title", "tag", "desc_html", "user_id").Where("archived_at IS NULL").Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	return recommend.NewIndex(articles), nil
+}
+
+// StoreRelated caches the related articles of one article. The entry
+// outlives a couple of refreshes so a slow refresh never leaves readers
+// without results.
+func StoreRelated(rdb *redis.Client, articleID uint, related []recommend.Scored, ttl time.Duration) error {
+	if related == nil {
+		related = []recommend.Scored{}
+	}
+	relatedJson, err := json.Marshal(related)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(recommend.RelatedKey(articleID), relatedJson, ttl).Err()
+}
+
+// RefreshRelated recomputes the related articles of every published
+// article.
+func RefreshRelated(a *app.App, ttl time.Duration) (int, error) {
+	index, err := RelatedIndex(a.Reader(0))
+	if err != nil {
+		return 0, err
+	}
+
+	ids := index.IDs()
+	for i, id := range ids {
+		if err := StoreRelated(a.RDB, id, index.Related(id, recommend.MaxRelated), ttl); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+func StartRelatedRefresh(ctx context.Context, wg *sync.WaitGroup, a *app.App, interval time.Duration) {
+	// Refresh right away so related articles are there from the start
+	// instead of an interval later.
+	every(ctx, wg, interval, true, func() {
+		if _, err := RefreshRelated(a, 3*interval); err != nil {
+			a.Logger.Error("Failed to refresh related articles", "error", err.Error())
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/app"
+)
+
+// StartReplicaHealthCheck pings the read replicas every interval, taking
+// the ones that stopped answering out of the rotation and putting back the
+// ones that recovered.
+func StartReplicaHealthCheck(ctx context.Context, wg *sync.WaitGroup, a *app.App, interval time.Duration) {
+	if a.Replicas.Len() == 0 {
+		return
+	}
+	every(ctx, wg, interval, false, a.Replicas.Check)
+}
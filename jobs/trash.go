@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/app"
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/storage"
+	"gorm.io/gorm"
+)
+
+func TrashRetention(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Storage.TrashRetentionDays) * 24 * time.Hour
+}
+
+// PurgeArticle hard deletes a (soft deleted) article. Attachment rows go
+// with it through the OnDelete:CASCADE constraint, the blobs are removed
+// afterwards when no other attachment points at them.
+func PurgeArticle(db *gorm.DB, store storage.BlobStore, article *models.Article) error {
+	var attachments []models.Attachment
+	if err := db.Unscoped().Where("article_id = ?", article.ID).Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	if err := db.Unscoped().Delete(article).Error; err != nil {
+		return err
+	}
+	ReleaseAttachments(db, store, attachments)
+	return nil
+}
+
+// ReleaseAttachments removes the blobs of attachments whose rows are gone,
+// unless another attachment still points at the same file. Every remaining
+// row counts, soft deleted ones too: the attachments of an article in the
+// trash come back when it is restored.
+func ReleaseAttachments(db *gorm.DB, store storage.BlobStore, attachments []models.Attachment) {
+	for _, attachment := range attachments {
+		var references int64
+		db.Unscoped().Model(&models.Attachment{}).Where("checksum = ?", attachment.Checksum).Count(&references)
+		if references > 0 {
+			continue
+		}
+		store.Delete(attachment.Key)
+		if attachment.ThumbnailKey != "" {
+			store.Delete(attachment.ThumbnailKey)
+		}
+	}
+}
+
+// PurgeExpiredTrash hard deletes every article that has been in the trash
+// longer than the retention period.
+func PurgeExpiredTrash(a *app.App) (int, error) {
+	var expired []models.Article
+	cutoff := time.Now().Add(-TrashRetention(a.Config))
+	if err := a.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&expired).Error; err != nil {
+		return 0, err
+	}
+
+	for i := range expired {
+		if err := PurgeArticle(a.DB, a.Storage, &expired[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(expired), nil
+}
+
+func StartTrashRetention(ctx context.Context, wg *sync.WaitGroup, a *app.App, interval time.Duration) {
+	every(ctx, wg, interval, false, func() {
+		purged, err := PurgeExpiredTrash(a)
+		if err != nil {
+			a.Logger.Error("Failed to purge trash", "error", err.Error())
+			return
+		}
+		if purged > 0 {
+			a.Logger.Info("Purged articles from trash", "articles", purged)
+		}
+	})
+}
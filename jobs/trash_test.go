@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	a, user := testApp(t)
+	a.Config.Storage.TrashRetentionDays = 30
+
+	deletedAt := map[string]time.Time{
+		"expired": time.Now().AddDate(0, 0, -31),
+		"recent":  time.Now().AddDate(0, 0, -1),
+	}
+	articles := map[string]*models.Article{}
+	for _, slug := range []string{"expired", "recent", "live"} {
+		article := &models.Article{Title: slug, Slug: slug, Tag: "go", UserID: user.ID}
+		assert.NoError(t, a.DB.Create(article).Error)
+		if when, ok := deletedAt[slug]; ok {
+			assert.NoError(t, a.DB.Unscoped().Model(article).Update("deleted_at", when).Error)
+		}
+		articles[slug] = article
+	}
+	key := "attachments/expired.pdf"
+	assert.NoError(t, a.Storage.Put(key, strings.NewReader("%PDF"), "application/pdf"))
+	assert.NoError(t, a.DB.Create(&models.Attachment{ArticleID: articles["expired"].ID, Checksum: "expired", Key: key}).Error)
+
+	purged, err := PurgeExpiredTrash(a)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	var left []string
+	assert.NoError(t, a.DB.Unscoped().Model(&models.Article{}).Order("id").Pluck("slug", &left).Error)
+	assert.Equal(t, []string{"recent", "live"}, left)
+	var attachments int64
+	assert.NoError(t, a.DB.Model(&models.Attachment{}).Count(&attachments).Error)
+	assert.Zero(t, attachments)
+	_, err = a.Storage.Get(key)
+	assert.Error(t, err)
+}
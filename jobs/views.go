@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/analytics"
+	"github.com/ArdhanaGusti/Golang_api/app"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// takeViews moves the unflushed reads of one article and day out of Redis.
+// The counter is swapped for zero and the referrers hash renamed away so
+// reads arriving meanwhile are left for the next flush.
+func takeViews(rdb *redis.Client, articleID uint, day time.Time) (analytics.Pending, error) {
+	pending := analytics.Pending{Referrers: map[string]int64{}}
+
+	var err error
+	if pending.UniqueReaders, err = rdb.PFCount(analytics.UniqueKey(articleID, day)).Result(); err != nil {
+		return pending, err
+	}
+
+	totalKey := analytics.TotalKey(articleID, day)
+	views, err := rdb.GetSet(totalKey, 0).Int64()
+	if err != nil && err != redis.Nil {
+		return pending, err
+	}
+	rdb.Expire(totalKey, analytics.BufferTTL)
+	pending.Views = views
+
+	referrersKey := analytics.ReferrersKey(articleID, day)
+	flushingKey := referrersKey + ":flushing"
+	if err := rdb.Rename(referrersKey, flushingKey).Err(); err != nil {
+		// Nothing to rename when every read was already flushed.
+		return pending, nil
+	}
+	referrers, err := rdb.HGetAll(flushingKey).Result()
+	if err != nil {
+		return pending, err
+	}
+	rdb.Del(flushingKey)
+	for referrer, count := range referrers {
+		if n, err := strconv.ParseInt(count, 10, 64); err == nil {
+			pending.Referrers[referrer] = n
+		}
+	}
+	return pending, nil
+}
+
+// giveBackViews puts taken reads back when they could not be saved.
+func giveBackViews(rdb *redis.Client, value string, articleID uint, day time.Time, pending analytics.Pending) {
+	rdb.IncrBy(analytics.TotalKey(articleID, day), pending.Views)
+	for referrer, count := range pending.Referrers {
+		rdb.HIncrBy(analytics.ReferrersKey(articleID, day), referrer, count)
+	}
+	rdb.SAdd(analytics.DirtyKey, value)
+}
+
+func saveViews(db *gorm.DB, articleID uint, day time.Time, pending analytics.Pending) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		view := models.ArticleView{
+			ArticleID:     articleID,
+			Day:           day.Format(analytics.DateLayout),
+			Views:         uint64(pending.Views),
+			UniqueReaders: uint64(pending.UniqueReaders),
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "article_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"views":          gorm.Expr("article_views.views + ?", pending.Views),
+				"unique_readers": pending.UniqueReaders,
+			}),
+		}).Create(&view).Error; err != nil {
+			return err
+		}
+
+		for referrer, count := range pending.Referrers {
+			row := models.ArticleReferrer{
+				ArticleID: articleID,
+				Day:       day.Format(analytics.DateLayout),
+				Referrer:  referrer,
+				Views:     uint64(count),
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "article_id"}, {Name: "day"}, {Name: "referrer"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"views": gorm.Expr("article_referrers.views + ?", count),
+				}),
+			}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FlushViews writes the reads buffered in Redis to the database.
+func FlushViews(a *app.App) (int, error) {
+	flushed := 0
+	for {
+		value, err := a.RDB.SPop(analytics.DirtyKey).Result()
+		if err == redis.Nil {
+			return flushed, nil
+		}
+		if err != nil {
+			return flushed, err
+		}
+		articleID, day, ok := analytics.ParseMember(value)
+		if !ok {
+			continue
+		}
+
+		pending, err := takeViews(a.RDB, articleID, day)
+		if err != nil {
+			a.RDB.SAdd(analytics.DirtyKey, value)
+			return flushed, err
+		}
+		if err := saveViews(a.DB, articleID, day, pending); err != nil {
+			// Reads of a purged article have nowhere to go anymore.
+			var article models.Article
+			if errors.Is(a.DB.Unscoped().Select("id").First(&article, articleID).Error, gorm.ErrRecordNotFound) {
+				continue
+			}
+			giveBackViews(a.RDB, value, articleID, day, pending)
+			return flushed, err
+		}
+		flushed++
+	}
+}
+
+func StartViewFlush(ctx context.Context, wg *sync.WaitGroup, a *app.App, interval time.Duration) {
+	every(ctx, wg, interval, false, func() {
+		if _, err := FlushViews(a); err != nil {
+			a.Logger.Error("Failed to flush article views", "error", err.Error())
+		}
+	})
+}
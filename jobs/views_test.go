@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/analytics"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlushViews(t *testing.T) {
+	a, user := testApp(t)
+	article := models.Article{Title: "Read", Slug: "read", Tag: "go", UserID: user.ID}
+	assert.NoError(t, a.DB.Create(&article).Error)
+	day := analytics.Day(time.Now()).Format(analytics.DateLayout)
+
+	assert.NoError(t, analytics.RecordView(a.RDB, article.ID, "first", "example.com"))
+	assert.NoError(t, analytics.RecordView(a.RDB, article.ID, "second", "example.com"))
+	assert.NoError(t, analytics.RecordView(a.RDB, article.ID, "first", analytics.DirectReferrer))
+	// Reads of an article purged before the flush are dropped.
+	assert.NoError(t, analytics.RecordView(a.RDB, article.ID+1, "first", analytics.DirectReferrer))
+
+	flushed, err := FlushViews(a)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, flushed)
+
+	var view models.ArticleView
+	assert.NoError(t, a.DB.First(&view, "article_id = ? AND day = ?", article.ID, day).Error)
+	assert.Equal(t, uint64(3), view.Views)
+	assert.Equal(t, uint64(2), view.UniqueReaders)
+	referrers := map[string]uint64{}
+	var rows []models.ArticleReferrer
+	assert.NoError(t, a.DB.Find(&rows, "article_id = ?", article.ID).Error)
+	for _, row := range rows {
+		referrers[row.Referrer] = row.Views
+	}
+	assert.Equal(t, map[string]uint64{"example.com": 2, analytics.DirectReferrer: 1}, referrers)
+
+	// Nothing is left in Redis to count twice, later reads add up.
+	pending, err := analytics.PendingViews(a.RDB, article.ID, analytics.Day(time.Now()))
+	assert.NoError(t, err)
+	assert.Zero(t, pending.Views)
+	assert.Empty(t, pending.Referrers)
+
+	assert.NoError(t, analytics.RecordView(a.RDB, article.ID, "third", "example.com"))
+	flushed, err = FlushViews(a)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, flushed)
+	assert.NoError(t, a.DB.First(&view, view.ID).Error)
+	assert.Equal(t, uint64(4), view.Views)
+	assert.Equal(t, uint64(3), view.UniqueReaders)
+	var referrer models.ArticleReferrer
+	assert.NoError(t, a.DB.First(&referrer, "article_id = ? AND referrer = ?", article.ID, "example.com").Error)
+	assert.Equal(t, uint64(3), referrer.Views)
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type gormLogger struct {
+	logger *slog.Logger
+	level  slog.Level
+	slow   time.Duration
+}
+
+// GORM logs the queries of a database: failed ones at error, ones taking
+// longer than slow at warn and every other one at info, leaving out those
+// below level. Queries run WithContext of a request log with its logger.
+func GORM(logger *slog.Logger, level slog.Level, slow time.Duration) gormlogger.Interface {
+	return &gormLogger{logger: logger, level: level, slow: slow}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	switch level {
+	case gormlogger.Silent:
+		copied.level = LevelOff
+	case gormlogger.Error:
+		copied.level = slog.LevelError
+	case gormlogger.Warn:
+		copied.level = slog.LevelWarn
+	default:
+		copied.level = slog.LevelInfo
+	}
+	return &copied
+}
+
+func (l *gormLogger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if level < l.level {
+		return
+	}
+	FromContext(ctx, l.logger).Log(ctx, level, msg, args...)
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, fmt.Sprintf(msg, args...))
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelWarn, fmt.Sprintf(msg, args...))
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelError, fmt.Sprintf(msg, args...))
+}
+
+// ParamsFilter keeps the values out of the logged SQL, they hold password
+// hashes and tokens.
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level >= LevelOff {
+		return
+	}
+	elapsed := time.Since(begin)
+	level, msg := slog.LevelInfo, "query"
+	switch {
+	// A missing record is how lookups answer 404, not a failure.
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "query failed"
+	case l.slow > 0 && elapsed > l.slow:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if level < l.level {
+		return
+	}
+
+	sql, rows := fc()
+	args := []any{"sql", sql, "rows", rows, "duration_ms", milliseconds(elapsed)}
+	if level == slog.LevelError {
+		args = append(args, "error", err.Error())
+	}
+	FromContext(ctx, l.logger).Log(ctx, level, msg, args...)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
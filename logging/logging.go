@@ -0,0 +1,65 @@
+// Package logging builds the structured logger of the API and hooks the
+// database and Redis clients into it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// LevelOff is above every level a record is logged at, a logger or hook at
+// LevelOff stays quiet.
+const LevelOff = slog.LevelError + 4
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ParseLevel reads debug, info, warn, error or off.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "off", "silent":
+		return LevelOff, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, use debug, info, warn, error or off", name)
+}
+
+// New writes records of level and up to w, as JSON lines unless format is
+// text.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	options := &slog.HandlerOptions{Level: level}
+	if format == FormatText {
+		return slog.New(slog.NewTextHandler(w, options))
+	}
+	return slog.New(slog.NewJSONHandler(w, options))
+}
+
+type contextKey struct{}
+
+// WithLogger attaches the logger of a request to ctx, so code further down,
+// such as the database logger, logs with its request ID.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext is the logger attached to ctx, fallback when there is none.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func records(t *testing.T, logs *bytes.Buffer) []map[string]interface{} {
+	var all []map[string]interface{}
+	decoder := json.NewDecoder(logs)
+	for decoder.More() {
+		var record map[string]interface{}
+		assert.NoError(t, decoder.Decode(&record))
+		all = append(all, record)
+	}
+	return all
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	level, err = ParseLevel("off")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelOff, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestGORMLevels(t *testing.T) {
+	var logs bytes.Buffer
+	logger := New(&logs, FormatJSON, slog.LevelDebug)
+	db := GORM(logger, slog.LevelWarn, 100*time.Millisecond)
+	query := func() (string, int64) { return "SELECT 1", 1 }
+
+	db.Trace(context.Background(), time.Now(), query, nil)
+	db.Trace(context.Background(), time.Now(), query, gorm.ErrRecordNotFound)
+	assert.Empty(t, records(t, &logs), "fast and not found queries are below warn")
+
+	db.Trace(context.Background(), time.Now().Add(-time.Second), query, nil)
+	db.Trace(context.Background(), time.Now(), query, errors.New("syntax error"))
+	got := records(t, &logs)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "slow query", got[0]["msg"])
+		assert.Equal(t, "SELECT 1", got[0]["sql"])
+		assert.Equal(t, "query failed", got[1]["msg"])
+		assert.Equal(t, "syntax error", got[1]["error"])
+	}
+
+	// Queries of a request log with its logger.
+	ctx := WithLogger(context.Background(), logger.With("request_id", "abc"))
+	GORM(logger, slog.LevelInfo, 0).Trace(ctx, time.Now(), query, nil)
+	got = records(t, &logs)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "query", got[0]["msg"])
+		assert.Equal(t, "abc", got[0]["request_id"])
+	}
+
+	GORM(logger, LevelOff, 0).Trace(context.Background(), time.Now(), query, errors.New("syntax error"))
+	assert.Empty(t, records(t, &logs))
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// HookRedis logs the commands of rdb like GORM does queries: failed ones at
+// error, ones taking longer than slow at warn and every other one at info,
+// leaving out those below level. A missing key is not a failure.
+func HookRedis(rdb *redis.Client, logger *slog.Logger, level slog.Level, slow time.Duration) {
+	if level >= LevelOff {
+		return
+	}
+	logCmd := func(cmd redis.Cmder, elapsed time.Duration) {
+		cmdLevel, msg := commandLevel(cmd.Err(), elapsed, slow)
+		if cmdLevel < level {
+			return
+		}
+		args := []any{"command", cmd.Name(), "duration_ms", milliseconds(elapsed)}
+		if cmdLevel == slog.LevelError {
+			args = append(args, "error", cmd.Err().Error())
+		}
+		logger.Log(context.Background(), cmdLevel, msg, args...)
+	}
+
+	rdb.WrapProcess(func(process func(redis.Cmder) error) func(redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			begin := time.Now()
+			err := process(cmd)
+			logCmd(cmd, time.Since(begin))
+			return err
+		}
+	})
+	rdb.WrapProcessPipeline(func(process func([]redis.Cmder) error) func([]redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			begin := time.Now()
+			err := process(cmds)
+			elapsed := time.Since(begin)
+			for _, cmd := range cmds {
+				logCmd(cmd, elapsed)
+			}
+			return err
+		}
+	})
+}
+
+func commandLevel(err error, elapsed, slow time.Duration) (slog.Level, string) {
+	switch {
+	case err != nil && err != redis.Nil:
+		return slog.LevelError, "redis command failed"
+	case slow > 0 && elapsed > slow:
+		return slog.LevelWarn, "slow redis command"
+	}
+	return slog.LevelInfo, "redis command"
+}
@@ -1,33 +1,101 @@
 package main
 
 import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/app"
 	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/jobs"
 	"github.com/ArdhanaGusti/Golang_api/middleware"
 	"github.com/ArdhanaGusti/Golang_api/routes"
+	"github.com/ArdhanaGusti/Golang_api/server"
 	"github.com/gin-gonic/gin"
 	"github.com/subosito/gotenv"
 )
 
-func setupRouter() *gin.Engine {
-	r := gin.Default()
+func setupRouter(a *app.App) *gin.Engine {
+	// The request logger goes first, so even a panic gets logged with the
+	// request ID.
+	r := gin.New()
+	r.Use(middleware.RequestLogger(a.Logger, a.Config.Auth.JWTSecret), gin.CustomRecoveryWithWriter(io.Discard, middleware.Recover))
+	h := routes.NewHandler(a)
+	isAuth := middleware.IsAuth(a.Config.Auth.JWTSecret)
+	isAdmin := middleware.IsAdmin(a.Config.Auth.JWTSecret)
+
+	v1 := r.Group("/api/v1", middleware.ReadYourWrites(a.Replicas, a.Config.Auth.JWTSecret))
 
-	v1 := r.Group("/api/v1")
+	// Anything that depends on who is asking must never end up in a shared
+	// cache, public reads may be served by the CDN for a short while.
+	private := v1.Group("", middleware.CacheControl(a.Config.Cache.Private))
 	{
-		v1.GET("/auth/check", middleware.IsAuth(), routes.CheckToken)
-		v1.GET("/auth/:provider", routes.RedirectHandler)
-		v1.GET("/auth/:provider/callback", routes.CallbackHandler)
+		private.GET("/auth/check", isAuth, h.CheckToken)
+		private.GET("/auth/:provider", h.RedirectHandler)
+		private.GET("/auth/:provider/callback", h.CallbackHandler)
+
+		private.POST("/auth/register", h.RegisterUser)
+		private.POST("/auth/login", h.LoginUser)
+		private.PATCH("/auth/change-role", isAuth, h.ChangeRole)
+
+		private.GET("/auth/profile", isAuth, h.GetProfile)
+		private.PATCH("/auth/profile", isAuth, h.UpdateProfile)
+		private.PATCH("/auth/profile/password", isAuth, h.ChangePassword)
+		private.DELETE("/auth/profile", isAuth, h.DeleteAccount)
+		private.GET("/auth/verify-email", h.VerifyEmail)
+		private.POST("/auth/profile/avatar", isAuth, h.UploadAvatar)
+		private.DELETE("/auth/profile/avatar", isAuth, h.DeleteAvatar)
+
+		private.GET("/admin/users", isAdmin, h.ListUsers)
+		private.GET("/admin/articles/trash", isAdmin, h.TrashArticles)
+		private.POST("/admin/articles/trash/:slug/restore", isAdmin, h.RestoreArticle)
+		private.DELETE("/admin/articles/trash/:slug", isAdmin, h.PurgeArticle)
+		private.GET("/admin/articles/export", isAdmin, h.ExportArticles)
+		private.POST("/admin/articles/import", isAdmin, h.ImportArticles)
 
-		v1.POST("/auth/register", routes.RegisterUser)
-		v1.POST("/auth/login", routes.LoginUser)
-		v1.PATCH("/auth/change-role", middleware.IsAuth(), routes.ChangeRole)
+		private.GET("/article", isAuth, h.Home)
+		private.POST("/article", isAuth, h.PostArticle)
+		private.POST("/article/bulk", isAuth, h.BulkArticles)
+		private.PUT("/article/:slug", isAuth, h.UpdateArticle)
+		private.PATCH("/article/:slug", isAuth, h.PatchArticle)
+		private.DELETE("/article/:slug", isAdmin, h.DeleteArticle)
+
+		private.POST("/article/:slug/attachments", isAuth, h.UploadAttachment)
+		private.DELETE("/article/:slug/attachments/:id", isAuth, h.DeleteAttachment)
+
+		private.POST("/article/:slug/like", isAuth, h.LikeArticle)
+		private.DELETE("/article/:slug/like", isAuth, h.UnlikeArticle)
+		private.GET("/article/:slug/stats", isAuth, h.ArticleStats)
+	}
+
+	publicCache := middleware.CacheControl(a.Config.Cache.Public)
+	public := v1.Group("", publicCache)
+	{
+		public.GET("/article/trending", h.TrendingArticles)
+		public.GET("/article/:slug", h.GetArticle)
+		public.GET("/article/:slug/related", h.RelatedArticles)
+		public.GET("/avatars/:id", h.GetAvatar)
+		public.GET("/attachments/:id", h.GetAttachment)
+	}
 
-		v1.GET("/auth/profile", middleware.IsAuth(), routes.GetProfile)
+	r.GET("/metrics", middleware.CacheControl("no-store"), h.Metrics)
+	r.GET("/healthz", middleware.CacheControl("no-store"), h.Healthz)
+	r.GET("/readyz", middleware.CacheControl("no-store"), h.Readyz)
 
-		v1.GET("/article", middleware.IsAuth(), routes.Home)
-		v1.GET("/article/:slug", routes.GetArticle)
-		v1.POST("/article", middleware.IsAuth(), routes.PostArticle)
-		v1.PUT("/article/:slug", middleware.IsAuth(), routes.UpdateArticle)
-		v1.DELETE("/article/:slug", middleware.IsAdmin(), routes.DeleteArticle)
+	feed := r.Group("", publicCache)
+	{
+		feed.GET("/sitemap.xml", h.Sitemap)
+		feed.GET("/sitemaps/:page", h.SitemapPage)
+		for _, format := range []string{"rss", "atom", "json"} {
+			feed.GET("/feed."+format, h.Feed(format))
+			feed.GET("/tags/:tag/feed."+format, h.TagFeed(format))
+			feed.GET("/authors/:id/feed."+format, h.AuthorFeed(format))
+		}
 	}
 
 	return r
@@ -35,9 +103,44 @@ func setupRouter() *gin.Engine {
 
 func main() {
 	gotenv.Load()
-	config.InitDB()
-	config.InitRedis()
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	// Fatal errors are logged and end the process with a status of 1, the
+	// App's logger is not there yet when it fails to build.
+	a, err := app.New(config.InitConfig())
+	if err != nil {
+		slog.Error("Failed to start", "error", err.Error())
+		os.Exit(1)
+	}
+	slog.SetDefault(a.Logger)
+	if a.Config.MetricsToken == "" {
+		a.Logger.Warn("METRICS_TOKEN is not set, /metrics is public")
+	}
+
+	// Ctrl+C or SIGTERM, as sent on deploys, fails readiness, stops taking
+	// new connections and the jobs, and lets the requests and job runs in
+	// flight finish before the database and Redis are closed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var running sync.WaitGroup
+	jobs.StartTrashRetention(ctx, &running, a, time.Hour)
+	jobs.StartRelatedRefresh(ctx, &running, a, 15*time.Minute)
+	jobs.StartViewFlush(ctx, &running, a, time.Minute)
+	jobs.StartReplicaHealthCheck(ctx, &running, a, 10*time.Second)
 
-	r := setupRouter()
-	r.Run(":8080")
+	srv, err := server.New(a.Config.Server, setupRouter(a), a.Logger)
+	if err != nil {
+		a.Logger.Error("Failed to start", "error", err.Error())
+		os.Exit(1)
+	}
+
+	err = server.Run(ctx, srv, a.Config.Server, func() { a.Draining.Store(true) })
+	running.Wait()
+	a.Close()
+	if err != nil {
+		a.Logger.Error("Server stopped", "error", err.Error())
+		os.Exit(1)
+	}
 }
@@ -2,19 +2,41 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ArdhanaGusti/Golang_api/app"
 	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
 	"github.com/ArdhanaGusti/Golang_api/handler/validation"
+	"github.com/ArdhanaGusti/Golang_api/jobs"
+	"github.com/ArdhanaGusti/Golang_api/logging"
+	"github.com/ArdhanaGusti/Golang_api/middleware"
 	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
 	"github.com/stretchr/testify/assert"
 	"github.com/subosito/gotenv"
+	"gorm.io/gorm"
 )
 
 type LoginResponse struct {
@@ -22,16 +44,63 @@ type LoginResponse struct {
 	Message string `json:"message"`
 }
 
-func Initialize() {
+// Initialize builds an App of its own for a test, closed once the test is
+// done.
+func Initialize(t *testing.T) *app.App {
 	gotenv.Load()
-	config.InitDB()
-	config.InitRedis()
+	a, err := app.New(config.InitConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+// jsonRequest builds a request sending payload as JSON, with token as the
+// Authorization header unless it is empty.
+func jsonRequest(method, path, token string, payload interface{}) *http.Request {
+	var body io.Reader
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		body = bytes.NewReader(data)
+	}
+	req, _ := http.NewRequest(method, path, body)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return req
+}
+
+func serve(router *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// signUp registers username@example.com, unless an earlier run did, and
+// returns their token, so a test does not depend on the users of others.
+func signUp(t *testing.T, router *gin.Engine, username string) string {
+	serve(router, jsonRequest(http.MethodPost, "/api/v1/auth/register", "", validation.RegisterUserPayload{
+		Username: username,
+		Fullname: username,
+		Email:    username + "@example.com",
+		Password: "admin123",
+	}))
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/auth/login", "", validation.LoginUserPayload{
+		Email:    username + "@example.com",
+		Password: "admin123",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var login LoginResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	return login.Token
 }
 
 func TestRegisterUser(t *testing.T) {
-	Initialize()
-	config.MigrateFreshDB()
-	router := setupRouter()
+	a := Initialize(t)
+	assert.NoError(t, config.ResetDB(a.DB, a.Config.DB.Driver))
+	router := setupRouter(a)
 
 	newUser := validation.RegisterUserPayload{
 		Username: "Rena",
@@ -53,8 +122,7 @@ func TestRegisterUser(t *testing.T) {
 }
 
 func TestLoginUser(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -76,8 +144,7 @@ func TestLoginUser(t *testing.T) {
 }
 
 func TestGetUser(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -100,7 +167,7 @@ func TestGetUser(t *testing.T) {
 	router.ServeHTTP(w2, req2)
 
 	assert.Equal(t, http.StatusOK, w2.Code)
-	var user models.User
+	var user response.SelfUserResponse
 	err2 := json.Unmarshal(w2.Body.Bytes(), &user)
 	assert.NoError(t, err2)
 	assert.Equal(t, "rena.aliana@yahoo.com", user.Email)
@@ -109,8 +176,7 @@ func TestGetUser(t *testing.T) {
 }
 
 func TestChangeEmailUser(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -141,8 +207,7 @@ func TestChangeEmailUser(t *testing.T) {
 }
 
 func TestCreateArticle(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -178,8 +243,7 @@ func TestCreateArticle(t *testing.T) {
 }
 
 func TestGetArticles(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -203,7 +267,7 @@ func TestGetArticles(t *testing.T) {
 	router.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusOK, w2.Code)
 	println(w2.Body.String())
-	var articles []models.Article
+	var articles []response.ArticleResponse
 	err2 := json.Unmarshal(w2.Body.Bytes(), &articles)
 	assert.NoError(t, err2)
 	assert.GreaterOrEqual(t, 1, len(articles))
@@ -213,8 +277,7 @@ func TestGetArticles(t *testing.T) {
 }
 
 func TestGetArticle(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -237,7 +300,7 @@ func TestGetArticle(t *testing.T) {
 
 	router.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusOK, w2.Code)
-	var articles []models.Article
+	var articles []response.ArticleResponse
 	err2 := json.Unmarshal(w2.Body.Bytes(), &articles)
 	assert.NoError(t, err2)
 
@@ -246,7 +309,7 @@ func TestGetArticle(t *testing.T) {
 
 	router.ServeHTTP(w3, req3)
 	assert.Equal(t, http.StatusOK, w3.Code)
-	var article models.Article
+	var article response.ArticleResponse
 	err3 := json.Unmarshal(w3.Body.Bytes(), &article)
 	assert.NoError(t, err3)
 	assert.Equal(t, "Tupai terbang", article.Title)
@@ -255,8 +318,7 @@ func TestGetArticle(t *testing.T) {
 }
 
 func TestUpdateArticle(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -279,7 +341,7 @@ func TestUpdateArticle(t *testing.T) {
 
 	router.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusOK, w2.Code)
-	var articles []models.Article
+	var articles []response.ArticleResponse
 	err2 := json.Unmarshal(w2.Body.Bytes(), &articles)
 	assert.NoError(t, err2)
 
@@ -301,8 +363,7 @@ func TestUpdateArticle(t *testing.T) {
 }
 
 func TestDeleteArticle(t *testing.T) {
-	Initialize()
-	router := setupRouter()
+	router := setupRouter(Initialize(t))
 
 	existUser := validation.LoginUserPayload{
 		Email:    "rena.aliana@yahoo.com",
@@ -325,7 +386,7 @@ func TestDeleteArticle(t *testing.T) {
 
 	router.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusOK, w2.Code)
-	var articles []models.Article
+	var articles []response.ArticleResponse
 	err2 := json.Unmarshal(w2.Body.Bytes(), &articles)
 	assert.NoError(t, err2)
 
@@ -338,3 +399,796 @@ func TestDeleteArticle(t *testing.T) {
 	expectedResponse := fmt.Sprintf(`{"message":"Article %s Deleted Successfully"}`, articles[0].Title)
 	assert.Equal(t, expectedResponse, w3.Body.String())
 }
+
+func TestHealthProbes(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+
+	w1 := httptest.NewRecorder()
+	req1, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+	router.ServeHTTP(w1, req1)
+	assert.Equal(t, http.StatusOK, w1.Code)
+	assert.JSONEq(t, `{"Status":"ok"}`, w1.Body.String())
+
+	w2 := httptest.NewRecorder()
+	req2, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+	router.ServeHTTP(w2, req2)
+	assert.Equal(t, http.StatusOK, w2.Code)
+	var ready response.HealthResponse
+	assert.NoError(t, json.Unmarshal(w2.Body.Bytes(), &ready))
+	assert.Equal(t, response.StatusOK, ready.Status)
+	for _, name := range []string{"database", "redis", "migrations"} {
+		assert.Equal(t, response.StatusOK, ready.Checks[name].Status, name)
+	}
+
+	a.Draining.Store(true)
+	w3 := httptest.NewRecorder()
+	req3, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+	router.ServeHTTP(w3, req3)
+	assert.Equal(t, http.StatusServiceUnavailable, w3.Code)
+}
+
+func TestRequestID(t *testing.T) {
+	a := Initialize(t)
+	var logs bytes.Buffer
+	a.Logger = logging.New(&logs, logging.FormatJSON, slog.LevelInfo)
+	router := setupRouter(a)
+
+	w1 := httptest.NewRecorder()
+	req1, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+	req1.Header.Set(middleware.RequestIDHeader, "edge-42")
+	router.ServeHTTP(w1, req1)
+	assert.Equal(t, "edge-42", w1.Header().Get(middleware.RequestIDHeader))
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "edge-42", record["request_id"])
+	assert.Equal(t, "/healthz", record["route"])
+	assert.Equal(t, float64(http.StatusOK), record["status"])
+	assert.Contains(t, record, "latency_ms")
+
+	// Anything that could break the log line is replaced.
+	w2 := httptest.NewRecorder()
+	req2, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+	req2.Header.Set(middleware.RequestIDHeader, "forged\nline")
+	router.ServeHTTP(w2, req2)
+	assert.Len(t, w2.Header().Get(middleware.RequestIDHeader), 32)
+}
+
+func TestDeleteAccountReleasesFiles(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "cascade")
+
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+		Title: "Cascade", Desc: "Goes away with its author.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", "Cascade").Error)
+
+	key := "attachments/cascade.pdf"
+	assert.NoError(t, a.Storage.Put(key, strings.NewReader("%PDF"), "application/pdf"))
+	assert.NoError(t, a.DB.Create(&models.Attachment{ArticleID: article.ID, Checksum: "cascade", Key: key}).Error)
+
+	w = serve(router, jsonRequest(http.MethodDelete, "/api/v1/auth/profile", token, validation.DeleteAccountPayload{
+		Password: "admin123", Mode: "cascade",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err := a.Storage.Get(key)
+	assert.Error(t, err)
+}
+
+func TestTagFeedMatchesEachTag(t *testing.T) {
+	router := setupRouter(Initialize(t))
+	token := signUp(t, router, "tagger")
+
+	for title, tag := range map[string]string{
+		"Tagged first":  "Gopher ,web",
+		"Tagged second": "web,  gopher",
+		"Tagged near":   "gophers",
+	} {
+		w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+			Title: title, Desc: "Lands in the tag feeds.", Tag: tag,
+		}))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := serve(router, jsonRequest(http.MethodGet, "/tags/gopher/feed.json", "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Tagged first")
+	assert.Contains(t, w.Body.String(), "Tagged second")
+	assert.NotContains(t, w.Body.String(), "Tagged near")
+}
+
+func TestSharedAttachmentBlobIsReleasedOnce(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "sharer")
+
+	key := "attachments/shared.pdf"
+	assert.NoError(t, a.Storage.Put(key, strings.NewReader("%PDF"), "application/pdf"))
+	articles := make([]models.Article, 2)
+	attachments := make([]models.Attachment, 2)
+	for i, title := range []string{"Shared one", "Shared two"} {
+		w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+			Title: title, Desc: "Both attach the same file.", Tag: "test",
+		}))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, a.DB.Order("id desc").First(&articles[i], "title = ?", title).Error)
+		attachments[i] = models.Attachment{ArticleID: articles[i].ID, Checksum: "shared", Key: key}
+		assert.NoError(t, a.DB.Create(&attachments[i]).Error)
+	}
+
+	w := serve(router, jsonRequest(http.MethodDelete, fmt.Sprintf("/api/v1/article/%s/attachments/%d", articles[0].Slug, attachments[0].ID), token, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err := a.Storage.Get(key)
+	assert.NoError(t, err, "still attached to the second article")
+
+	assert.NoError(t, jobs.PurgeArticle(a.DB, a.Storage, &articles[1]))
+	_, err = a.Storage.Get(key)
+	assert.Error(t, err, "no attachment is left")
+}
+
+func TestRenamedArticleRedirects(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "mover")
+
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+		Title: "Moving slug", Desc: "Gets a new title.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", "Moving slug").Error)
+	oldSlug := article.Slug
+
+	w = serve(router, jsonRequest(http.MethodPut, "/api/v1/article/"+oldSlug+"?reslug=true", token, validation.CreateArticlePayload{
+		Title: "Moved slug", Desc: "Gets a new title.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, a.DB.First(&article, article.ID).Error)
+	assert.NotEqual(t, oldSlug, article.Slug)
+
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/article/"+oldSlug+"?format=text", "", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/api/v1/article/"+article.Slug+"?format=text", w.Header().Get("Location"))
+}
+
+func TestConditionalGetArticle(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "cacher")
+
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+		Title: "Cached article", Desc: "Served **once**.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", "Cached article").Error)
+	path := "/api/v1/article/" + article.Slug
+
+	w = serve(router, jsonRequest(http.MethodGet, path, "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+
+	req := jsonRequest(http.MethodGet, path, "", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = serve(router, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// Another format is another representation with an ETag of its own.
+	req = jsonRequest(http.MethodGet, path+"?format=html", "", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = serve(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	req = jsonRequest(http.MethodGet, path, "", nil)
+	req.Header.Set("If-Modified-Since", w.Header().Get("Last-Modified"))
+	w = serve(router, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestIfMatchGuardsWrites(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "guard")
+
+	payload := validation.CreateArticlePayload{Title: "Guarded article", Desc: "Edited twice.", Tag: "test"}
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, payload))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", payload.Title).Error)
+	path := "/api/v1/article/" + article.Slug
+
+	w = serve(router, jsonRequest(http.MethodGet, path, "", nil))
+	etag := w.Header().Get("ETag")
+
+	update := func(ifMatch string) int {
+		req := jsonRequest(http.MethodPut, path, token, payload)
+		req.Header.Set("If-Match", ifMatch)
+		return serve(router, req).Code
+	}
+	// A weak validator never matches If-Match.
+	assert.Equal(t, http.StatusPreconditionFailed, update("W/"+etag))
+	assert.Equal(t, http.StatusOK, update(etag))
+	// The first update bumped the version, the old ETag is stale now.
+	assert.Equal(t, http.StatusPreconditionFailed, update(etag))
+
+	assert.Equal(t, http.StatusOK, update("*"))
+}
+
+func TestPatchArticle(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "patcher")
+
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+		Title: "Patched article", Desc: "Before the patch.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", "Patched article").Error)
+
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1/article/"+article.Slug, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", token)
+		return serve(router, req)
+	}
+
+	for _, tt := range []struct {
+		name, contentType, body string
+		code                    int
+		tag, desc               string
+	}{
+		{"merge patch", "application/merge-patch+json", `{"Tag": "merged"}`, http.StatusOK, "merged", "Before the patch."},
+		{"json patch", "application/json-patch+json", `[{"op": "replace", "path": "/Desc", "value": "After the patch."}]`, http.StatusOK, "merged", "After the patch."},
+		{"failed json patch test", "application/json-patch+json", `[{"op": "test", "path": "/Tag", "value": "other"}, {"op": "replace", "path": "/Tag", "value": "lost"}]`, http.StatusUnprocessableEntity, "merged", "After the patch."},
+		{"merge patch removing a required field", "application/merge-patch+json", `{"Title": null}`, http.StatusUnprocessableEntity, "merged", "After the patch."},
+		{"merge patch with unknown field", "application/merge-patch+json", `{"Tag": "lost", "UserID": 1}`, http.StatusUnprocessableEntity, "merged", "After the patch."},
+		{"json patch with unknown field", "application/json-patch+json", `[{"op": "add", "path": "/Version", "value": 1}]`, http.StatusUnprocessableEntity, "merged", "After the patch."},
+		{"plain json", "application/json", `{"Tag": "lost"}`, http.StatusUnsupportedMediaType, "merged", "After the patch."},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := patch(tt.contentType, tt.body)
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+			var saved models.Article
+			assert.NoError(t, a.DB.First(&saved, article.ID).Error)
+			assert.Equal(t, tt.tag, saved.Tag)
+			assert.Equal(t, tt.desc, saved.Desc)
+			assert.Equal(t, "Patched article", saved.Title)
+		})
+	}
+}
+
+func TestBulkArticles(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "bulker")
+
+	articles := make([]models.Article, 2)
+	for i, title := range []string{"Bulk one", "Bulk two"} {
+		w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+			Title: title, Desc: "Changed in bulk.", Tag: "test",
+		}))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, a.DB.Order("id desc").First(&articles[i], "title = ?", title).Error)
+	}
+
+	bulk := func(atomic bool) (int, []routes.BulkOperationResult) {
+		w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article/bulk", token, validation.BulkArticlePayload{
+			Atomic: atomic,
+			Operations: []validation.BulkArticleOperation{
+				{Op: "retag", Slug: articles[0].Slug, Tag: "bulked"},
+				{Op: "retag", Slug: "bulk-missing", Tag: "bulked"},
+				{Op: "archive", Slug: articles[1].Slug},
+			},
+		}))
+		var body struct {
+			Results []routes.BulkOperationResult `json:"results"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body.Results
+	}
+	statusCodes := func(results []routes.BulkOperationResult) []int {
+		codes := make([]int, len(results))
+		for i, result := range results {
+			codes[i] = result.StatusCode
+		}
+		return codes
+	}
+
+	// One failure rolls the atomic batch back, the others depended on it.
+	code, results := bulk(true)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, []int{424, 404, 424}, statusCodes(results))
+	assert.NoError(t, a.DB.First(&articles[0], articles[0].ID).Error)
+	assert.Equal(t, "test", articles[0].Tag)
+	assert.NoError(t, a.DB.First(&articles[1], articles[1].ID).Error)
+	assert.Nil(t, articles[1].ArchivedAt)
+
+	code, results = bulk(false)
+	assert.Equal(t, http.StatusMultiStatus, code)
+	assert.Equal(t, []int{200, 404, 200}, statusCodes(results))
+	assert.NoError(t, a.DB.First(&articles[0], articles[0].ID).Error)
+	assert.Equal(t, "bulked", articles[0].Tag)
+	assert.NoError(t, a.DB.First(&articles[1], articles[1].ID).Error)
+	assert.NotNil(t, articles[1].ArchivedAt)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Setenv("METRICS_TOKEN", "metrics-secret-token")
+	router := setupRouter(Initialize(t))
+
+	w := serve(router, jsonRequest(http.MethodGet, "/metrics", "", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serve(router, jsonRequest(http.MethodGet, "/metrics", "Bearer wrong", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve(router, jsonRequest(http.MethodGet, "/metrics", "Bearer metrics-secret-token", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), "# TYPE golang_api_db_open_connections gauge\n")
+	assert.Contains(t, w.Body.String(), "# TYPE golang_api_db_wait_count_total counter\n")
+	assert.Regexp(t, `golang_api_db_max_open_connections\{driver="\w+"\} \d+`, w.Body.String())
+}
+
+func TestReadyzHidesCheckErrors(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+
+	rdb := a.RDB
+	a.RDB = redis.NewClient(&redis.Options{Network: "unix", Addr: t.TempDir() + "/redis.sock"})
+	defer func() {
+		a.RDB.Close()
+		a.RDB = rdb
+	}()
+
+	w := serve(router, jsonRequest(http.MethodGet, "/readyz", "", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var ready response.HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ready))
+	assert.Equal(t, response.StatusOK, ready.Checks["database"].Status)
+	assert.Equal(t, response.StatusUnavailable, ready.Checks["redis"].Status)
+	assert.Equal(t, "failed", ready.Checks["redis"].Error)
+	assert.NotContains(t, w.Body.String(), "redis.sock")
+}
+
+// fileRequest builds a multipart request uploading data as field.
+func fileRequest(method, path, token, field, filename string, data []byte) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile(field, filename)
+	part.Write(data)
+	form.Close()
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return req
+}
+
+// solidPNG encodes a small single colored picture.
+func solidPNG(t *testing.T, fill color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(img, img.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestAvatar(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "avatarer")
+	var user models.User
+	assert.NoError(t, a.DB.First(&user, "email = ?", "avatarer@example.com").Error)
+	path := models.AvatarPath(user.ID)
+	stored := func(checksum string) bool {
+		for _, size := range []int{32, 64, 128, 256} {
+			blob, err := a.Storage.Get("avatars/" + checksum + "/" + strconv.Itoa(size) + ".png")
+			if err != nil {
+				return false
+			}
+			blob.Close()
+		}
+		return true
+	}
+
+	// Without an upload every user gets an identicon.
+	w := serve(router, jsonRequest(http.MethodGet, path, "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	identicon := w.Header().Get("ETag")
+	assert.Contains(t, identicon, "identicon-")
+	req := jsonRequest(http.MethodGet, path, "", nil)
+	req.Header.Set("If-None-Match", identicon)
+	assert.Equal(t, http.StatusNotModified, serve(router, req).Code)
+
+	w = serve(router, fileRequest(http.MethodPost, "/api/v1/auth/profile/avatar", token, "Avatar", "avatar.txt", []byte("not a picture")))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = serve(router, fileRequest(http.MethodPost, "/api/v1/auth/profile/avatar", token, "Avatar", "red.png", solidPNG(t, color.RGBA{R: 255, A: 255})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, a.DB.First(&user, user.ID).Error)
+	first := user.AvatarChecksum
+	assert.NotEmpty(t, first)
+	assert.True(t, stored(first))
+
+	w = serve(router, jsonRequest(http.MethodGet, path+"?size=64", "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"`+first+`-64"`, w.Header().Get("ETag"))
+	decoded, err := png.Decode(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), decoded.Bounds())
+	// Sizes outside the list fall back to the default one.
+	w = serve(router, jsonRequest(http.MethodGet, path+"?size=1000", "", nil))
+	assert.Equal(t, `"`+first+`-128"`, w.Header().Get("ETag"))
+
+	// Replacing the picture drops the files of the old one.
+	w = serve(router, fileRequest(http.MethodPost, "/api/v1/auth/profile/avatar", token, "Avatar", "blue.png", solidPNG(t, color.RGBA{B: 255, A: 255})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, a.DB.First(&user, user.ID).Error)
+	second := user.AvatarChecksum
+	assert.NotEqual(t, first, second)
+	assert.False(t, stored(first))
+	assert.True(t, stored(second))
+
+	w = serve(router, jsonRequest(http.MethodDelete, "/api/v1/auth/profile/avatar", token, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, stored(second))
+	w = serve(router, jsonRequest(http.MethodGet, path, "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, identicon, w.Header().Get("ETag"))
+
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/avatars/999999", "", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestArticleStats(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "statist")
+	other := signUp(t, router, "snoop")
+
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+		Title: "Counted article", Desc: "Read a few times.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", "Counted article").Error)
+
+	for _, agent := range []string{"first", "second", "first"} {
+		req := jsonRequest(http.MethodGet, "/api/v1/article/"+article.Slug, "", nil)
+		req.Header.Set("User-Agent", agent)
+		req.Header.Set("Referer", "https://search.example.com/?q=tupai")
+		assert.Equal(t, http.StatusOK, serve(router, req).Code)
+	}
+
+	stats := func() response.ArticleStatsResponse {
+		w := serve(router, jsonRequest(http.MethodGet, "/api/v1/article/"+article.Slug+"/stats", token, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var stats response.ArticleStatsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		return stats
+	}
+	// Reads still buffered in Redis count, and count once flushed.
+	for _, flush := range []bool{false, true} {
+		if flush {
+			_, err := jobs.FlushViews(a)
+			assert.NoError(t, err)
+		}
+		got := stats()
+		assert.Len(t, got.Days, 30)
+		assert.Equal(t, uint64(3), got.Views)
+		assert.Equal(t, uint64(2), got.DailyUniqueReadersSum)
+		assert.Equal(t, []response.ReferrerStatsResponse{{Referrer: "search.example.com", Views: 3}}, got.Referrers)
+	}
+
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/article/"+article.Slug+"/stats", other, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/article/"+article.Slug+"/stats?from=2026-02-01&to=2026-01-01", token, nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// signUpAdmin registers username as an admin and returns their token.
+func signUpAdmin(t *testing.T, a *app.App, router *gin.Engine, username string) string {
+	signUp(t, router, username)
+	assert.NoError(t, a.DB.Model(&models.User{}).Where("email = ?", username+"@example.com").Update("role", true).Error)
+	// The role is in the token, log in again to get it.
+	return signUp(t, router, username)
+}
+
+func TestTrash(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	admin := signUpAdmin(t, a, router, "janitor")
+
+	articles := map[string]models.Article{}
+	for _, title := range []string{"Trash kept", "Trash restored", "Trash purged"} {
+		w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", admin, validation.CreateArticlePayload{
+			Title: title, Desc: "Goes through the trash.", Tag: "test",
+		}))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var article models.Article
+		assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", title).Error)
+		articles[title] = article
+	}
+	restored, purged := articles["Trash restored"], articles["Trash purged"]
+	for _, article := range []models.Article{restored, purged} {
+		w := serve(router, jsonRequest(http.MethodDelete, "/api/v1/article/"+article.Slug, admin, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	key := "attachments/trash.pdf"
+	assert.NoError(t, a.Storage.Put(key, strings.NewReader("%PDF"), "application/pdf"))
+	assert.NoError(t, a.DB.Create(&models.Attachment{ArticleID: purged.ID, Checksum: "trash", Key: key}).Error)
+
+	w := serve(router, jsonRequest(http.MethodGet, "/api/v1/admin/articles/trash", admin, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var trash []struct {
+		Article response.ArticleResponse
+		PurgeAt string
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	inTrash := map[string]bool{}
+	for _, entry := range trash {
+		inTrash[entry.Article.Slug] = true
+		assert.NotEmpty(t, entry.PurgeAt)
+	}
+	assert.True(t, inTrash[restored.Slug])
+	assert.True(t, inTrash[purged.Slug])
+	assert.False(t, inTrash[articles["Trash kept"].Slug])
+
+	// Restoring brings the article back under its own slug.
+	w = serve(router, jsonRequest(http.MethodPost, "/api/v1/admin/articles/trash/"+restored.Slug+"/restore", admin, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/article/"+restored.Slug, "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(router, jsonRequest(http.MethodPost, "/api/v1/admin/articles/trash/"+restored.Slug+"/restore", admin, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Only articles in the trash can be purged, with their attachments.
+	w = serve(router, jsonRequest(http.MethodDelete, "/api/v1/admin/articles/trash/"+articles["Trash kept"].Slug, admin, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(router, jsonRequest(http.MethodDelete, "/api/v1/admin/articles/trash/"+purged.Slug, admin, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.ErrorIs(t, a.DB.Unscoped().First(&models.Article{}, purged.ID).Error, gorm.ErrRecordNotFound)
+	var attachments int64
+	assert.NoError(t, a.DB.Model(&models.Attachment{}).Where("article_id = ?", purged.ID).Count(&attachments).Error)
+	assert.Zero(t, attachments)
+	_, err := a.Storage.Get(key)
+	assert.Error(t, err)
+
+	// Admins only.
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/admin/articles/trash", signUp(t, router, "visitor"), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestProfile(t *testing.T) {
+	a := Initialize(t)
+	a.Config.Mail.LogMessages = true
+	router := setupRouter(a)
+	token := signUp(t, router, "profiler")
+	signUp(t, router, "taken")
+	var user models.User
+	assert.NoError(t, a.DB.First(&user, "email = ?", "profiler@example.com").Error)
+
+	// Usernames stay unique, whatever their case.
+	taken := "Taken"
+	w := serve(router, jsonRequest(http.MethodPatch, "/api/v1/auth/profile", token, validation.UpdateProfilePayload{Username: &taken}))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	own := "profiler"
+	w = serve(router, jsonRequest(http.MethodPatch, "/api/v1/auth/profile", token, validation.UpdateProfilePayload{Username: &own}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// A new email waits for its owner to confirm it.
+	email := "profiler-new@example.com"
+	w = serve(router, jsonRequest(http.MethodPatch, "/api/v1/auth/profile", token, validation.UpdateProfilePayload{Email: &email}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, a.DB.First(&user, user.ID).Error)
+	assert.Equal(t, "profiler@example.com", user.Email)
+	assert.Equal(t, email, user.PendingEmail)
+	assert.Len(t, user.EmailVerifyToken, 64)
+	if assert.NotNil(t, user.EmailVerifyExpiresAt) {
+		assert.True(t, user.EmailVerifyExpiresAt.After(time.Now()))
+	}
+
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/auth/verify-email?token=wrong", "", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	sum := sha256.Sum256([]byte("expired"))
+	assert.NoError(t, a.DB.Model(&user).Updates(map[string]interface{}{
+		"email_verify_token":      hex.EncodeToString(sum[:]),
+		"email_verify_expires_at": time.Now().Add(-time.Minute),
+	}).Error)
+	w = serve(router, jsonRequest(http.MethodGet, "/api/v1/auth/verify-email?token=expired", "", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.NoError(t, a.DB.First(&user, user.ID).Error)
+	assert.Equal(t, "profiler@example.com", user.Email)
+
+	// The current password has to be right before it is replaced.
+	w = serve(router, jsonRequest(http.MethodPatch, "/api/v1/auth/profile/password", token, validation.ChangePasswordPayload{
+		CurrentPassword: "wrong123", NewPassword: "admin1234",
+	}))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Anonymizing keeps the articles under the placeholder author.
+	w = serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+		Title: "Anonymized", Desc: "Stays after its author leaves.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.First(&article, "user_id = ? AND title = ?", user.ID, "Anonymized").Error)
+
+	w = serve(router, jsonRequest(http.MethodDelete, "/api/v1/auth/profile", token, validation.DeleteAccountPayload{
+		Password: "admin123", Mode: "anonymize",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.ErrorIs(t, a.DB.Unscoped().First(&models.User{}, user.ID).Error, gorm.ErrRecordNotFound)
+	var author models.User
+	assert.NoError(t, a.DB.First(&article, article.ID).Error)
+	assert.NoError(t, a.DB.First(&author, article.UserID).Error)
+	assert.Equal(t, "deleted", author.Username)
+}
+
+func TestImportBodyIsLimited(t *testing.T) {
+	a := Initialize(t)
+	a.Config.Storage.ImportMaxSize = 64
+	router := setupRouter(a)
+	admin := signUpAdmin(t, a, router, "importer")
+
+	record := `{"slug":"limited","title":"Limited","tag":"test","author":"importer@example.com","desc":"Too long for the limit."}`
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/articles/import?dry_run=true", strings.NewReader(record))
+	req.Header.Set("Authorization", admin)
+	w := serve(router, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	a.Config.Storage.ImportMaxSize = 1 << 20
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/admin/articles/import?dry_run=true", strings.NewReader(record))
+	req.Header.Set("Authorization", admin)
+	w = serve(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+type sitemapDocument struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func TestSitemap(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	admin := signUpAdmin(t, a, router, "cartographer")
+
+	articles := map[string]models.Article{}
+	for _, title := range []string{"Mapped", "Mapped too", "Mapped archived", "Mapped trashed"} {
+		w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", admin, validation.CreateArticlePayload{
+			Title: title, Desc: "Shows up in the sitemap, or not.", Tag: "test",
+		}))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var article models.Article
+		assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", title).Error)
+		articles[title] = article
+	}
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, a.DB.Model(&models.Article{}).Where("id = ?", articles["Mapped"].ID).UpdateColumn("updated_at", updatedAt).Error)
+	assert.NoError(t, a.DB.Model(&models.Article{}).Where("id = ?", articles["Mapped archived"].ID).UpdateColumn("archived_at", time.Now()).Error)
+	w := serve(router, jsonRequest(http.MethodDelete, "/api/v1/article/"+articles["Mapped trashed"].Slug, admin, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Only published articles are listed, dated by their last update.
+	w = serve(router, jsonRequest(http.MethodGet, "/sitemap.xml", "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var doc sitemapDocument
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "urlset", doc.XMLName.Local)
+	lastMods := map[string]string{}
+	for _, u := range doc.URLs {
+		lastMods[u.Loc] = u.LastMod
+	}
+	assert.Equal(t, "2026-03-01T12:00:00Z", lastMods[a.Config.ArticleURL(articles["Mapped"].Slug)])
+	assert.NotContains(t, lastMods, a.Config.ArticleURL(articles["Mapped archived"].Slug))
+	assert.NotContains(t, lastMods, a.Config.ArticleURL(articles["Mapped trashed"].Slug))
+
+	// Past one page the sitemap becomes an index of pages.
+	var published int64
+	assert.NoError(t, a.DB.Model(&models.Article{}).Where("archived_at IS NULL").Count(&published).Error)
+	a.Config.Feed.SitemapPageSize = 1
+	w = serve(router, jsonRequest(http.MethodGet, "/sitemap.xml", "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	doc = sitemapDocument{}
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "sitemapindex", doc.XMLName.Local)
+	if assert.Len(t, doc.Sitemaps, int(published)) {
+		assert.Equal(t, a.Config.AppURL+"/sitemaps/1.xml", doc.Sitemaps[0].Loc)
+	}
+
+	w = serve(router, jsonRequest(http.MethodGet, "/sitemaps/"+strconv.FormatInt(published, 10)+".xml", "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	doc = sitemapDocument{}
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Len(t, doc.URLs, 1)
+	for _, page := range []string{strconv.FormatInt(published+1, 10) + ".xml", "0.xml", "first.xml"} {
+		w = serve(router, jsonRequest(http.MethodGet, "/sitemaps/"+page, "", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, page)
+	}
+}
+
+func TestAttachmentOfTrashedArticle(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	author := signUp(t, router, "attacher")
+	stranger := signUp(t, router, "stranger")
+	admin := signUpAdmin(t, a, router, "warden")
+
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", author, validation.CreateArticlePayload{
+		Title: "Attached", Desc: "Carries a picture.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", "Attached").Error)
+	w = serve(router, fileRequest(http.MethodPost, "/api/v1/article/"+article.Slug+"/attachments", author, "File", "picture.png", solidPNG(t, color.RGBA{1, 2, 3, 255})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var attachment models.Attachment
+	assert.NoError(t, a.DB.Order("id desc").First(&attachment, "article_id = ?", article.ID).Error)
+	path := "/api/v1/attachments/" + strconv.Itoa(int(attachment.ID))
+
+	w = serve(router, jsonRequest(http.MethodGet, path, "", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Contains(t, w.Header().Get("Cache-Control"), "public")
+
+	// In the trash the file is only served to the author and admins.
+	w = serve(router, jsonRequest(http.MethodDelete, "/api/v1/article/"+article.Slug, admin, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, token := range []string{"", stranger} {
+		w = serve(router, jsonRequest(http.MethodGet, path, token, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+	for _, token := range []string{author, admin} {
+		w = serve(router, jsonRequest(http.MethodGet, path, token, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestFailedAttachmentReleasesBlob(t *testing.T) {
+	a := Initialize(t)
+	router := setupRouter(a)
+	token := signUp(t, router, "unlucky")
+	w := serve(router, jsonRequest(http.MethodPost, "/api/v1/article", token, validation.CreateArticlePayload{
+		Title: "Unlucky", Desc: "Its attachment never lands.", Tag: "test",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var article models.Article
+	assert.NoError(t, a.DB.Order("id desc").First(&article, "title = ?", "Unlucky").Error)
+
+	assert.NoError(t, a.DB.Callback().Create().Before("gorm:create").Register("test:broken_attachment", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*models.Attachment); ok {
+			tx.AddError(errors.New("broken"))
+		}
+	}))
+	data := solidPNG(t, color.RGBA{4, 5, 6, 255})
+	w = serve(router, fileRequest(http.MethodPost, "/api/v1/article/"+article.Slug+"/attachments", token, "File", "picture.png", data))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	for _, key := range []string{"attachments/" + checksum + ".png", "attachments/thumbs/" + checksum + ".jpg"} {
+		_, err := a.Storage.Get(key)
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl sets a default Cache-Control header for a route group.
+// Handlers can still override it, e.g. for immutable blobs.
+func CacheControl(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy != "" && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+			c.Header("Cache-Control", policy)
+		}
+		c.Next()
+	}
+}
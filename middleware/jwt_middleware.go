@@ -2,37 +2,78 @@ package middleware
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/ArdhanaGusti/Golang_api/handler/failed"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-func IsAuth() gin.HandlerFunc {
-	return CheckJwt(false)
+func IsAuth(secret string) gin.HandlerFunc {
+	return CheckJwt(secret, false)
 }
 
-func IsAdmin() gin.HandlerFunc {
-	return CheckJwt(true)
+func IsAdmin(secret string) gin.HandlerFunc {
+	return CheckJwt(secret, true)
 }
 
-func CheckJwt(admin bool) gin.HandlerFunc {
+func parseToken(secret, authHeader string) (*jwt.Token, error) {
+	return jwt.Parse(authHeader, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(secret), nil
+	})
+}
+
+// optionalClaims checks the token sent anyway to a public route, which
+// does not require one. It returns nil when there is no valid token.
+func optionalClaims(c *gin.Context, secret string) jwt.MapClaims {
+	authHeader := c.Request.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil
+	}
+	token, err := parseToken(secret, authHeader)
+	if err != nil || !token.Valid {
+		return nil
+	}
+	claims, _ := token.Claims.(jwt.MapClaims)
+	return claims
+}
+
+// UserID is the user sending the request, 0 when anonymous.
+func UserID(c *gin.Context, secret string) uint {
+	userID, ok := c.Get("jwt_user_id")
+	if !ok {
+		userID = optionalClaims(c, secret)["user_id"]
+	}
+	if id, ok := userID.(float64); ok && id > 0 {
+		return uint(id)
+	}
+	return 0
+}
+
+// IsAdminUser reports whether the request comes from an admin, false when
+// anonymous.
+func IsAdminUser(c *gin.Context, secret string) bool {
+	role, ok := c.Get("jwt_user_role")
+	if !ok {
+		role = optionalClaims(c, secret)["user_role"]
+	}
+	admin, _ := role.(bool)
+	return admin
+}
+
+func CheckJwt(secret string, admin bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.Request.Header.Get("Authorization")
 		// bearerToken := strings.Split(authHeader, " ")
-		token, err := jwt.Parse(authHeader, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		token, err := parseToken(secret, authHeader)
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			fmt.Println(claims["user_id"], claims["user_role"])
 			userRole := bool(claims["user_role"].(bool))
 			c.Set("jwt_user_id", claims["user_id"])
+			c.Set("jwt_user_role", userRole)
 
 			if admin == true && userRole == false {
 				c.JSON(403, failed.FailedResponse{
@@ -42,7 +83,6 @@ func CheckJwt(admin bool) gin.HandlerFunc {
 				c.Abort()
 				return
 			}
-			// c.Set("jwt_user_role", claims["user_role"])
 		} else {
 			c.JSON(422, failed.FailedResponse{
 				StatusCode: 422,
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// validRequestID keeps what clients or proxies send from breaking the log
+// lines it ends up in.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// RequestLogger gives every request an ID, the X-Request-ID it came with or
+// a new one, sends it back in the response and logs the request once it is
+// done. Handlers log through Logger(c), so their records carry the ID too.
+func RequestLogger(logger *slog.Logger, secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+
+		requestLogger := logger.With("request_id", id)
+		c.Set("logger", requestLogger)
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), requestLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		args := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if userID := UserID(c, secret); userID != 0 {
+			args = append(args, "user_id", userID)
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, "errors", c.Errors.String())
+		}
+		requestLogger.Log(c.Request.Context(), level, "request", args...)
+	}
+}
+
+// Logger is the logger of the request, carrying its ID.
+func Logger(c *gin.Context) *slog.Logger {
+	if logger, ok := c.Get("logger"); ok {
+		return logger.(*slog.Logger)
+	}
+	return logging.FromContext(c.Request.Context(), slog.Default())
+}
+
+// Recover answers a panicking handler with a 500 and logs the panic with
+// its stack, instead of gin printing it to stderr.
+func Recover(c *gin.Context, recovered any) {
+	Logger(c).Error("panic", "error", recovered, "stack", string(debug.Stack()))
+	c.AbortWithStatusJSON(http.StatusInternalServerError, failed.FailedResponse{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "Internal Server Error",
+	})
+}
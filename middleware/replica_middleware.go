@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites keeps the reads of a user on the primary for a moment
+// after each of their successful writes, the replicas may not have it yet.
+func ReadYourWrites(replicas *config.Replicas, secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		if c.Writer.Status() >= 400 {
+			return
+		}
+		replicas.StickToPrimary(UserID(c, secret))
+	}
+}
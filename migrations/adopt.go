@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Baseline is the migration holding the schema AutoMigrate used to keep.
+// Databases created before the migrations already have some of its tables,
+// in whatever shape the API had back then.
+const Baseline uint64 = 20261019000000
+
+var (
+	createTable = regexp.MustCompile("(?s)^CREATE TABLE IF NOT EXISTS ([`\"](\\w+)[`\"]) \\((.*)\\);?$")
+	columnName  = regexp.MustCompile("^[`\"](\\w+)[`\"] ")
+	inlineIndex = regexp.MustCompile("^(?:UNIQUE )?INDEX [`\"](\\w+)[`\"] ")
+)
+
+// tableExists tells whether statement is a CREATE TABLE IF NOT EXISTS that
+// will be skipped because the table is already there.
+func tableExists(tx *gorm.DB, statement string) bool {
+	match := createTable.FindStringSubmatch(statement)
+	return match != nil && tx.Migrator().HasTable(match[2])
+}
+
+// adopt adds the columns, and on MySQL the indexes, that statement defines
+// but the existing table lacks. Columns already there keep their type and
+// data.
+func adopt(tx *gorm.DB, migration Migration, statement string) error {
+	match := createTable.FindStringSubmatch(statement)
+	quotedTable, table := match[1], match[2]
+	for _, line := range strings.Split(match[3], "\n") {
+		definition := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		var alter string
+		if column := columnName.FindStringSubmatch(definition); column != nil {
+			if tx.Migrator().HasColumn(table, column[1]) {
+				continue
+			}
+			alter = "ALTER TABLE " + quotedTable + " ADD COLUMN " + definition
+		} else if index := inlineIndex.FindStringSubmatch(definition); index != nil {
+			if tx.Migrator().HasIndex(table, index[1]) {
+				continue
+			}
+			alter = "ALTER TABLE " + quotedTable + " ADD " + definition
+		} else {
+			continue
+		}
+		if err := tx.Exec(alter).Error; err != nil {
+			return fmt.Errorf("%s: adopting %s: %w", migration, table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	lockName = "golang_api_migrations"
+	// lockKey is the PostgreSQL advisory lock key, any constant unlikely to
+	// clash with other locks on the same database does.
+	lockKey = 0x676f6170696d6967
+
+	lockTimeoutSeconds = 300
+)
+
+// withLock runs fn holding a database wide lock so only one instance
+// migrates at a time. Advisory locks belong to a session, so everything
+// runs on the one connection that took the lock. SQLite already allows
+// only one writer to its file and needs no lock.
+func withLock(db *gorm.DB, driver string, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		switch driver {
+		case "mysql":
+			var locked sql.NullInt64
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, lockTimeoutSeconds).Row().Scan(&locked); err != nil {
+				return err
+			}
+			if !locked.Valid || locked.Int64 != 1 {
+				return ErrLocked
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		case "postgres":
+			deadline := time.Now().Add(lockTimeoutSeconds * time.Second)
+			for {
+				var locked bool
+				if err := conn.Raw("SELECT pg_try_advisory_lock(?)", int64(lockKey)).Row().Scan(&locked); err != nil {
+					return err
+				}
+				if locked {
+					break
+				}
+				if time.Now().After(deadline) {
+					return ErrLocked
+				}
+				time.Sleep(time.Second)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", int64(lockKey))
+		}
+		return fn(conn)
+	})
+}
@@ -0,0 +1,350 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Every driver has its own directory of SQL files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql. The version is
+// the UTC time the migration was created at, so migrations written on
+// different branches never share a number.
+//
+//go:embed sql
+var files embed.FS
+
+const (
+	Dir           = "migrations/sql"
+	versionLayout = "20060102150405"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	ErrNoDown = errors.New("migration has no down step")
+	ErrLocked = errors.New("another instance is migrating the database")
+)
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+	HasDown bool
+}
+
+func (m Migration) String() string {
+	return strconv.FormatUint(m.Version, 10) + "_" + m.Name
+}
+
+// SchemaMigration is one applied migration.
+type SchemaMigration struct {
+	Version   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	// Missing is an applied migration without its files in this build.
+	Missing bool
+}
+
+// Load reads the migrations of a driver, oldest first.
+func Load(driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql/"+driver)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+
+	byVersion := map[uint64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseUint(match[1], 10, 64)
+		content, err := files.ReadFile("sql/" + driver + "/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", migration.Name, match[2], version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+			migration.HasDown = true
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// statements splits a migration into the statements it runs one by one,
+// not every driver takes several statements in one Exec. A statement ends
+// with a semicolon at the end of a line, lines starting with -- are
+// comments.
+func statements(sql string) []string {
+	var (
+		result  []string
+		current strings.Builder
+	)
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
+
+// createHistory creates the schema_migrations table on the first migration.
+// Only Up and Down call it, holding the lock: reading the history, as the
+// readiness probe does, never changes the schema.
+func createHistory(db *gorm.DB) error {
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil
+	}
+	return db.Migrator().CreateTable(&SchemaMigration{})
+}
+
+// applied reads the migrations recorded as applied, none while the
+// schema_migrations table does not exist yet.
+func applied(db *gorm.DB) (map[uint64]SchemaMigration, error) {
+	result := map[uint64]SchemaMigration{}
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return result, nil
+	}
+
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// run executes the statements of a migration, then its Go step if it has
+// one, and records it in one transaction. MySQL commits every DDL statement
+// on its own though, a migration failing there halfway leaves the
+// statements before applied and the migration unrecorded.
+func run(db *gorm.DB, migration Migration, sql string, up bool, record func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements(sql) {
+			adopting := up && migration.Version == Baseline && tableExists(tx, statement)
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("%s: %w", migration, err)
+			}
+			if adopting {
+				if err := adopt(tx, migration, statement); err != nil {
+					return err
+				}
+			}
+		}
+		if step, ok := steps[migration.Version]; up && ok {
+			if err := step(tx); err != nil {
+				return fmt.Errorf("%s: %w", migration, err)
+			}
+		}
+		return record(tx)
+	})
+}
+
+// Up applies up to steps pending migrations, all of them when steps is 0,
+// and returns the ones it applied.
+func Up(db *gorm.DB, driver string, steps int) ([]Migration, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withLock(db, driver, func(conn *gorm.DB) error {
+		// Read what is applied only once the lock is held, another
+		// instance may just have finished migrating.
+		if err := createHistory(conn); err != nil {
+			return err
+		}
+		appliedMigrations, err := applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := appliedMigrations[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(done) == steps {
+				break
+			}
+			if err := run(conn, migration, migration.Up, true, func(tx *gorm.DB) error {
+				return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			}); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, all of them when steps
+// is 0, newest first.
+func Down(db *gorm.DB, driver string, steps int) ([]Migration, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withLock(db, driver, func(conn *gorm.DB) error {
+		appliedMigrations, err := applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			migration := migrations[i]
+			if _, ok := appliedMigrations[migration.Version]; !ok {
+				continue
+			}
+			if steps > 0 && len(done) == steps {
+				break
+			}
+			if !migration.HasDown {
+				return fmt.Errorf("%s: %w", migration, ErrNoDown)
+			}
+			if err := run(conn, migration, migration.Down, false, func(tx *gorm.DB) error {
+				return tx.Delete(&SchemaMigration{}, migration.Version).Error
+			}); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Statuses lists every migration with whether and when it was applied.
+func Statuses(db *gorm.DB, driver string) ([]Status, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+	appliedMigrations, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, migration := range migrations {
+		status := Status{Migration: migration}
+		if row, ok := appliedMigrations[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(appliedMigrations, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range appliedMigrations {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, Status{
+			Migration: Migration{Version: row.Version, Name: row.Name},
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending counts the migrations not applied yet.
+func Pending(db *gorm.DB, driver string) (int, error) {
+	statuses, err := Statuses(db, driver)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Create writes empty up and down files of a new migration for every
+// driver under dir and returns their paths.
+func Create(dir, name string, now time.Time) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+
+	drivers, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+	version := now.UTC().Format(versionLayout)
+
+	var paths []string
+	for _, driver := range drivers {
+		if !driver.IsDir() {
+			continue
+		}
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, driver.Name(), version+"_"+name+"."+direction+".sql")
+			content := "-- " + name + " (" + direction + ") for " + driver.Name() + "\n"
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
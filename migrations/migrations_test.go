@@ -0,0 +1,152 @@
+package migrations
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestStatements(t *testing.T) {
+	sql := "-- comment\nCREATE TABLE a (\n  id int\n);\n\nCREATE INDEX b ON a (id);\nDROP TABLE c"
+	assert.Equal(t, []string{
+		"CREATE TABLE a (\n  id int\n);",
+		"CREATE INDEX b ON a (id);",
+		"DROP TABLE c",
+	}, statements(sql))
+}
+
+// Every driver must ship the same migrations, otherwise a schema depends on
+// which database it runs on.
+func TestDriversHaveSameMigrations(t *testing.T) {
+	mysql, err := Load("mysql")
+	assert.NoError(t, err)
+	for _, driver := range []string{"postgres", "sqlite"} {
+		migrations, err := Load(driver)
+		assert.NoError(t, err)
+		if assert.Len(t, migrations, len(mysql), driver) {
+			for i := range migrations {
+				assert.Equal(t, mysql[i].String(), migrations[i].String(), driver)
+				assert.True(t, migrations[i].HasDown, migrations[i].String())
+			}
+		}
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	done, err := Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, done)
+	assert.True(t, db.Migrator().HasTable("articles"))
+
+	pending, err := Pending(db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+
+	done, err = Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, done)
+
+	done, err = Down(db, "sqlite", 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, done)
+	assert.False(t, db.Migrator().HasTable("articles"))
+
+	pending, err = Pending(db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, len(done), pending)
+}
+
+// A database AutoMigrate created before the migrations keeps its rows and
+// gets the columns added since.
+func TestUpAdoptsAutoMigrateSchema(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	for _, statement := range []string{
+		"CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`username` text,`fullname` text,`email` text,`password` text,`social_id` text,`provider` text,`avatar` text,`role` numeric DEFAULT 0)",
+		"CREATE TABLE `articles` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`title` text,`tag` text,`slug` text,`desc` text,`user_id` integer)",
+		"INSERT INTO `users` (`id`, `username`, `email`) VALUES (1, 'Rena', 'rena.aliana@yahoo.com')",
+		"INSERT INTO `articles` (`title`, `slug`, `user_id`) VALUES ('Hello', 'hello', 1)",
+	} {
+		assert.NoError(t, db.Exec(statement).Error)
+	}
+
+	_, err = Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	for _, column := range []string{"desc_html", "version", "archived_at", "excerpt", "canonical_url", "og_image"} {
+		assert.True(t, db.Migrator().HasColumn("articles", column), column)
+	}
+	assert.True(t, db.Migrator().HasColumn("users", "pending_email"))
+
+	var version int
+	assert.NoError(t, db.Raw("SELECT version FROM articles WHERE slug = 'hello'").Scan(&version).Error)
+	assert.Equal(t, 1, version)
+}
+
+func TestUpRendersOldDescriptions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	_, err = Up(db, "sqlite", 1)
+	assert.NoError(t, err)
+	for _, statement := range []string{
+		"INSERT INTO users (id, username, email) VALUES (1, 'Rena', 'rena.aliana@yahoo.com')",
+		"INSERT INTO articles (title, slug, tag, \"desc\", desc_html, user_id) VALUES ('Old', 'old', 'go', 'Tupai **terbang**', '', 1)",
+		"INSERT INTO articles (title, slug, tag, \"desc\", desc_html, user_id) VALUES ('New', 'new', 'go', 'Tupai', '<p>Kept</p>', 1)",
+	} {
+		assert.NoError(t, db.Exec(statement).Error)
+	}
+
+	_, err = Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	var html []string
+	assert.NoError(t, db.Raw("SELECT desc_html FROM articles ORDER BY id").Scan(&html).Error)
+	assert.Equal(t, []string{"<p>Tupai <strong>terbang</strong></p>\n", "<p>Kept</p>"}, html)
+}
+
+func TestUpNormalizesOldTags(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	_, err = Up(db, "sqlite", 1)
+	assert.NoError(t, err)
+	tags := []string{"go", "go,web", " Go ,,  web   api ,", "go, Go", " , "}
+	assert.NoError(t, db.Exec("INSERT INTO users (id, username, email) VALUES (1, 'Rena', 'rena.aliana@yahoo.com')").Error)
+	for i, tag := range tags {
+		assert.NoError(t, db.Exec("INSERT INTO articles (title, slug, tag, user_id) VALUES (?, ?, ?, 1)", tag, fmt.Sprint("article-", i), tag).Error)
+	}
+
+	_, err = Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	var normalized []string
+	assert.NoError(t, db.Raw("SELECT tag FROM articles ORDER BY id").Scan(&normalized).Error)
+	assert.Equal(t, []string{"go", "go, web", "Go, web api", "go", ""}, normalized)
+}
+
+func TestPendingOnEmptyDatabaseOnlyReads(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	migrations, err := Load("sqlite")
+	assert.NoError(t, err)
+	pending, err := Pending(db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), pending)
+	assert.False(t, db.Migrator().HasTable(&SchemaMigration{}))
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS `article_referrers`;
+DROP TABLE IF EXISTS `article_views`;
+DROP TABLE IF EXISTS `article_likes`;
+DROP TABLE IF EXISTS `slug_histories`;
+DROP TABLE IF EXISTS `attachments`;
+DROP TABLE IF EXISTS `articles`;
+DROP TABLE IF EXISTS `users`;
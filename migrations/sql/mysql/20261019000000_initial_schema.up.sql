@@ -0,0 +1,112 @@
+-- The schema as AutoMigrate used to create it. Tables of databases created
+-- that way are kept with their data, the migrator adds the columns they
+-- lack (see migrations/adopt.go), since IF NOT EXISTS alone would skip them.
+-- MySQL does not roll back DDL: when this fails halfway, fix the cause and
+-- run it again, adopting skips what is already there.
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `username` longtext,
+  `fullname` longtext,
+  `email` longtext,
+  `pending_email` longtext,
+  `email_verify_token` longtext,
+  `email_verify_expires_at` datetime(3) NULL,
+  `password` longtext,
+  `social_id` longtext,
+  `provider` longtext,
+  `avatar` longtext,
+  `avatar_checksum` longtext,
+  `role` boolean DEFAULT false,
+  PRIMARY KEY (`id`),
+  INDEX `idx_users_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `articles` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `title` longtext,
+  `tag` longtext,
+  `slug` varchar(191),
+  `desc` longtext,
+  `desc_html` text,
+  `excerpt` text,
+  `meta_description` longtext,
+  `canonical_url` longtext,
+  `og_image` longtext,
+  `version` bigint unsigned NOT NULL DEFAULT 1,
+  `archived_at` datetime(3) NULL,
+  `user_id` bigint unsigned,
+  PRIMARY KEY (`id`),
+  INDEX `idx_articles_deleted_at` (`deleted_at`),
+  UNIQUE INDEX `idx_articles_slug` (`slug`),
+  CONSTRAINT `fk_users_articles` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `attachments` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `article_id` bigint unsigned,
+  `file_name` longtext,
+  `content_type` longtext,
+  `size` bigint,
+  `checksum` longtext,
+  `key` longtext,
+  `thumbnail_key` longtext,
+  `width` bigint,
+  `height` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_attachments_deleted_at` (`deleted_at`),
+  CONSTRAINT `fk_articles_attachments` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `slug_histories` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `slug` varchar(191),
+  `article_id` bigint unsigned,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_slug_histories_slug` (`slug`),
+  CONSTRAINT `fk_articles_slug_histories` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `article_likes` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `article_id` bigint unsigned,
+  `user_id` bigint unsigned,
+  PRIMARY KEY (`id`),
+  INDEX `idx_article_likes_user_id` (`user_id`),
+  UNIQUE INDEX `idx_article_likes_article_user` (`article_id`, `user_id`),
+  CONSTRAINT `fk_articles_likes` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_users_likes` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `article_views` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `article_id` bigint unsigned,
+  `day` varchar(10),
+  `views` bigint unsigned,
+  `unique_readers` bigint unsigned,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_article_views_article_day` (`article_id`, `day`),
+  CONSTRAINT `fk_articles_views` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `article_referrers` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `article_id` bigint unsigned,
+  `day` varchar(10),
+  `referrer` varchar(191),
+  `views` bigint unsigned,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_article_referrers_article_day_referrer` (`article_id`, `day`, `referrer`),
+  CONSTRAINT `fk_articles_referrers` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
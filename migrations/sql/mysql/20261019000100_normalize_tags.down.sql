@@ -0,0 +1 @@
+-- The spelling the tags had before is not kept, the normalized tags stay.
@@ -0,0 +1,2 @@
+-- Tags are rewritten with recommend.NormalizeTags in Go, see normalizeTags in
+-- migrations/steps.go, so old rows match what the API stores now.
@@ -0,0 +1,110 @@
+-- The schema as AutoMigrate used to create it. Tables of databases created
+-- that way are kept with their data, the migrator adds the columns they
+-- lack (see migrations/adopt.go), since IF NOT EXISTS alone would skip them.
+
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" bigserial,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "username" text,
+  "fullname" text,
+  "email" text,
+  "pending_email" text,
+  "email_verify_token" text,
+  "email_verify_expires_at" timestamptz,
+  "password" text,
+  "social_id" text,
+  "provider" text,
+  "avatar" text,
+  "avatar_checksum" text,
+  "role" boolean DEFAULT false,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "articles" (
+  "id" bigserial,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "title" text,
+  "tag" text,
+  "slug" varchar(191),
+  "desc" text,
+  "desc_html" text,
+  "excerpt" text,
+  "meta_description" text,
+  "canonical_url" text,
+  "og_image" text,
+  "version" bigint NOT NULL DEFAULT 1,
+  "archived_at" timestamptz,
+  "user_id" bigint,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_users_articles" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_articles_slug" ON "articles" ("slug");
+CREATE INDEX IF NOT EXISTS "idx_articles_deleted_at" ON "articles" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "attachments" (
+  "id" bigserial,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "article_id" bigint,
+  "file_name" text,
+  "content_type" text,
+  "size" bigint,
+  "checksum" text,
+  "key" text,
+  "thumbnail_key" text,
+  "width" bigint,
+  "height" bigint,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_articles_attachments" FOREIGN KEY ("article_id") REFERENCES "articles"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_attachments_deleted_at" ON "attachments" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "slug_histories" (
+  "id" bigserial,
+  "created_at" timestamptz,
+  "slug" varchar(191),
+  "article_id" bigint,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_articles_slug_histories" FOREIGN KEY ("article_id") REFERENCES "articles"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_slug_histories_slug" ON "slug_histories" ("slug");
+
+CREATE TABLE IF NOT EXISTS "article_likes" (
+  "id" bigserial,
+  "created_at" timestamptz,
+  "article_id" bigint,
+  "user_id" bigint,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_articles_likes" FOREIGN KEY ("article_id") REFERENCES "articles"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT "fk_users_likes" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_article_likes_user_id" ON "article_likes" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_article_likes_article_user" ON "article_likes" ("article_id", "user_id");
+
+CREATE TABLE IF NOT EXISTS "article_views" (
+  "id" bigserial,
+  "article_id" bigint,
+  "day" varchar(10),
+  "views" bigint,
+  "unique_readers" bigint,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_articles_views" FOREIGN KEY ("article_id") REFERENCES "articles"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_article_views_article_day" ON "article_views" ("article_id", "day");
+
+CREATE TABLE IF NOT EXISTS "article_referrers" (
+  "id" bigserial,
+  "article_id" bigint,
+  "day" varchar(10),
+  "referrer" varchar(191),
+  "views" bigint,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_articles_referrers" FOREIGN KEY ("article_id") REFERENCES "articles"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_article_referrers_article_day_referrer" ON "article_referrers" ("article_id", "day", "referrer");
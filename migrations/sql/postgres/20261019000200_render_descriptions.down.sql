@@ -0,0 +1 @@
+-- The rendered descriptions stay.
@@ -0,0 +1,103 @@
+-- The schema as AutoMigrate used to create it. Tables of databases created
+-- that way are kept with their data, the migrator adds the columns they
+-- lack (see migrations/adopt.go), since IF NOT EXISTS alone would skip them.
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `username` text,
+  `fullname` text,
+  `email` text,
+  `pending_email` text,
+  `email_verify_token` text,
+  `email_verify_expires_at` datetime,
+  `password` text,
+  `social_id` text,
+  `provider` text,
+  `avatar` text,
+  `avatar_checksum` text,
+  `role` numeric DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `articles` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `title` text,
+  `tag` text,
+  `slug` text,
+  `desc` text,
+  `desc_html` text,
+  `excerpt` text,
+  `meta_description` text,
+  `canonical_url` text,
+  `og_image` text,
+  `version` integer NOT NULL DEFAULT 1,
+  `archived_at` datetime,
+  `user_id` integer,
+  CONSTRAINT `fk_users_articles` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_articles_slug` ON `articles`(`slug`);
+CREATE INDEX IF NOT EXISTS `idx_articles_deleted_at` ON `articles`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `attachments` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `article_id` integer,
+  `file_name` text,
+  `content_type` text,
+  `size` integer,
+  `checksum` text,
+  `key` text,
+  `thumbnail_key` text,
+  `width` integer,
+  `height` integer,
+  CONSTRAINT `fk_articles_attachments` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS `idx_attachments_deleted_at` ON `attachments`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `slug_histories` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `slug` text,
+  `article_id` integer,
+  CONSTRAINT `fk_articles_slug_histories` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_slug_histories_slug` ON `slug_histories`(`slug`);
+
+CREATE TABLE IF NOT EXISTS `article_likes` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `article_id` integer,
+  `user_id` integer,
+  CONSTRAINT `fk_articles_likes` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_users_likes` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS `idx_article_likes_user_id` ON `article_likes`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_article_likes_article_user` ON `article_likes`(`article_id`, `user_id`);
+
+CREATE TABLE IF NOT EXISTS `article_views` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `article_id` integer,
+  `day` text,
+  `views` integer,
+  `unique_readers` integer,
+  CONSTRAINT `fk_articles_views` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_article_views_article_day` ON `article_views`(`article_id`, `day`);
+
+CREATE TABLE IF NOT EXISTS `article_referrers` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `article_id` integer,
+  `day` text,
+  `referrer` text,
+  `views` integer,
+  CONSTRAINT `fk_articles_referrers` FOREIGN KEY (`article_id`) REFERENCES `articles`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_article_referrers_article_day_referrer` ON `article_referrers`(`article_id`, `day`, `referrer`);
@@ -0,0 +1,2 @@
+-- desc_html is rendered from desc in Go, see renderDescriptions in
+-- migrations/steps.go.
@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/recommend"
+	"gorm.io/gorm"
+)
+
+const stepBatchSize = 500
+
+// steps change data in ways SQL can not express the same on every driver.
+// A step runs in Go right after the SQL of the migration with its version,
+// in the same transaction. Steps only go up, rolling the migration back
+// keeps the data as the step left it.
+var steps = map[uint64]func(tx *gorm.DB) error{
+	20261019000100: normalizeTags,
+	20261019000200: renderDescriptions,
+}
+
+type articleRow struct {
+	ID       uint
+	Tag      string
+	Desc     string
+	DescHTML string
+}
+
+// eachArticle calls fn for the articles matching where, trashed ones too,
+// loading them in batches.
+func eachArticle(tx *gorm.DB, where string, fn func(row articleRow) error) error {
+	var last uint
+	for {
+		var rows []articleRow
+		if err := tx.Table("articles").Where("id > ?", last).Where(where).Order("id").Limit(stepBatchSize).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < stepBatchSize {
+			return nil
+		}
+		last = rows[len(rows)-1].ID
+	}
+}
+
+// normalizeTags stores the tags of every article the way
+// recommend.NormalizeTags leaves them, as tag feeds expect.
+func normalizeTags(tx *gorm.DB) error {
+	return eachArticle(tx, "tag IS NOT NULL AND tag <> ''", func(row articleRow) error {
+		tag := recommend.NormalizeTags(row.Tag)
+		if tag == row.Tag {
+			return nil
+		}
+		return tx.Exec("UPDATE articles SET tag = ? WHERE id = ?", tag, row.ID).Error
+	})
+}
+
+// renderDescriptions fills desc_html for the articles written before it was
+// rendered on save, everything reading articles expects it.
+func renderDescriptions(tx *gorm.DB) error {
+	return eachArticle(tx, "desc_html IS NULL OR desc_html = ''", func(row articleRow) error {
+		html, err := markdown.Render(row.Desc)
+		if err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE articles SET desc_html = ? WHERE id = ?", html, row.ID).Error
+	})
+}
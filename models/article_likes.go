@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// ArticleLike is one user liking one article, a user can only like an
+// article once.
+type ArticleLike struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	ArticleID uint `gorm:"uniqueIndex:idx_article_likes_article_user"`
+	UserID    uint `gorm:"uniqueIndex:idx_article_likes_article_user;index"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// ArticleView is how often an article was read on one day, flushed from the
+// counters in Redis. Day is the UTC date as YYYY-MM-DD so it means the same
+// whatever the time zone of the database connection.
+type ArticleView struct {
+	ID            uint   `gorm:"primarykey"`
+	ArticleID     uint   `gorm:"uniqueIndex:idx_article_views_article_day"`
+	Day           string `gorm:"size:10;uniqueIndex:idx_article_views_article_day"`
+	Views         uint64
+	UniqueReaders uint64
+}
+
+// ArticleReferrer is how many reads of an article on one day came from one
+// referring host.
+type ArticleReferrer struct {
+	ID        uint   `gorm:"primarykey"`
+	ArticleID uint   `gorm:"uniqueIndex:idx_article_referrers_article_day_referrer"`
+	Day       string `gorm:"size:10;uniqueIndex:idx_article_referrers_article_day_referrer"`
+	Referrer  string `gorm:"size:191;uniqueIndex:idx_article_referrers_article_day_referrer"`
+	Views     uint64
+}
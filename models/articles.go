@@ -1,15 +1,29 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type Article struct {
 	gorm.Model
-	Title  string
-	Tag    string
-	Slug   string `gorm:"unique_index"`
-	Desc   string `sql:"type:text;"`
-	UserID uint
-	User   User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Title           string
+	Tag             string
+	Slug            string `gorm:"uniqueIndex;size:191"`
+	Desc            string `sql:"type:text;"`
+	DescHTML        string `gorm:"type:text"`
+	Excerpt         string `gorm:"type:text"`
+	MetaDescription string
+	CanonicalURL    string
+	OGImage         string
+	Version         uint `gorm:"not null;default:1"`
+	ArchivedAt      *time.Time
+	UserID          uint
+	User            User              `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Attachments     []Attachment      `gorm:"foreignKey:ArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	SlugHistories   []SlugHistory     `gorm:"foreignKey:ArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Likes           []ArticleLike     `gorm:"foreignKey:ArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Views           []ArticleView     `gorm:"foreignKey:ArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Referrers       []ArticleReferrer `gorm:"foreignKey:ArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type Attachment struct {
+	gorm.Model
+	ArticleID    uint
+	FileName     string
+	ContentType  string
+	Size         int64
+	Checksum     string
+	Key          string
+	ThumbnailKey string
+	Width        int
+	Height       int
+}
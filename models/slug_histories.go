@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// SlugHistory remembers slugs an article used to have so old links can be
+// redirected to the current one.
+type SlugHistory struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Slug      string `gorm:"uniqueIndex;size:191"`
+	ArticleID uint
+}
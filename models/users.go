@@ -1,18 +1,32 @@
 package models
 
 import (
+	"strconv"
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type User struct {
 	gorm.Model
-	Articles []Article `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Username string
-	Fullname string
-	Email    string
-	Password string
-	SocialID string
-	Provider string
-	Avatar   string
-	Role     bool `gorm:"default:0"`
+	Articles             []Article     `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Likes                []ArticleLike `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Username             string
+	Fullname             string
+	Email                string
+	PendingEmail         string
+	EmailVerifyToken     string
+	EmailVerifyExpiresAt *time.Time
+	Password             string
+	SocialID             string
+	Provider             string
+	Avatar               string
+	AvatarChecksum       string
+	Role                 bool `gorm:"default:false"`
+}
+
+// AvatarPath is the stable URL of a user's avatar, it keeps working whether
+// the user uploaded one, came from a social provider or has an identicon.
+func AvatarPath(id uint) string {
+	return "/api/v1/avatars/" + strconv.FormatUint(uint64(id), 10)
 }
@@ -0,0 +1,67 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestRelated(t *testing.T) {
+	articles := []models.Article{
+		{Model: gorm.Model{ID: 1}, Title: "Tupai terbang", Tag: "fiction, animal", DescHTML: "<p>Tupai itu terbang ke langit.</p>", UserID: 1},
+		{Model: gorm.Model{ID: 2}, Title: "Tupai berdiri", Tag: "animal", DescHTML: "<p>Tupai itu berdiri ke arah timur.</p>", UserID: 2},
+		{Model: gorm.Model{ID: 3}, Title: "Resep nasi goreng", Tag: "food", DescHTML: "<p>Nasi goreng dengan telur.</p>", UserID: 2},
+		{Model: gorm.Model{ID: 4}, Title: "Cuaca hari ini", Tag: "news", DescHTML: "<p>Hujan deras di sore hari.</p>", UserID: 3},
+	}
+	index := NewIndex(articles)
+
+	related := index.Related(1, MaxRelated)
+	if assert.Len(t, related, 1) {
+		assert.Equal(t, uint(2), related[0].ArticleID)
+	}
+
+	related = index.Related(2, MaxRelated)
+	if assert.Len(t, related, 2) {
+		assert.Equal(t, uint(1), related[0].ArticleID)
+		assert.Equal(t, uint(3), related[1].ArticleID)
+	}
+
+	assert.Empty(t, index.Related(4, MaxRelated))
+	assert.Nil(t, index.Related(5, MaxRelated))
+}
+
+func TestTrending(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	views := []DailyViews{
+		{Day: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC), Counts: map[uint]float64{1: 10, 2: 10}},
+		{Day: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), Counts: map[uint]float64{3: 30}},
+		{Day: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Counts: map[uint]float64{4: 1000}},
+	}
+	likes := []Like{
+		{ArticleID: 2, CreatedAt: now.Add(-time.Hour)},
+	}
+
+	trending := Trending(views, likes, now, 10)
+	ids := make([]uint, 0, len(trending))
+	for _, scored := range trending {
+		ids = append(ids, scored.ArticleID)
+	}
+	assert.Equal(t, []uint{2, 1, 3}, ids)
+	assert.Len(t, Trending(views, likes, now, 1), 1)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	cases := map[string]string{
+		"go":                 "go",
+		"go,web":             "go, web",
+		"  Go ,  web  api ,": "Go, web api",
+		"go, Go, web":        "go, web",
+		" , ":                "",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, NormalizeTags(in), in)
+	}
+}
@@ -0,0 +1,203 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/models"
+)
+
+// How much each signal counts towards the final score, they add up to 1.
+const (
+	textWeight   = 0.5
+	tagWeight    = 0.35
+	authorWeight = 0.15
+
+	// titleBoost repeats title terms so they weigh more than body terms.
+	titleBoost = 3
+)
+
+// MaxRelated is how many related articles are kept for each article.
+const MaxRelated = 20
+
+type Scored struct {
+	ArticleID uint    `json:"ArticleID"`
+	Score     float64 `json:"Score"`
+}
+
+// best sorts by score, newest article first on a tie, and keeps the top
+// limit.
+func best(scored []Scored, limit int) []Scored {
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score == scored[j].Score {
+			return scored[i].ArticleID > scored[j].ArticleID
+		}
+		return scored[i].Score > scored[j].Score
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
+
+type document struct {
+	id     uint
+	userID uint
+	tags   map[string]bool
+	vector map[string]float64
+	norm   float64
+}
+
+// Index holds TF-IDF vectors of a set of articles so any of them can be
+// compared against the rest.
+type Index struct {
+	documents []document
+	byID      map[uint]int
+}
+
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	tokens := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) > 2 {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// Tags splits the free form Tag column, authors separate several tags with
+// commas.
+func Tags(tag string) map[string]bool {
+	tags := map[string]bool{}
+	for _, t := range strings.Split(tag, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			tags[t] = true
+		}
+	}
+	return tags
+}
+
+// NormalizeTags rewrites the Tag column into the form stored in the
+// database: each tag trimmed, empty and repeated tags dropped and the rest
+// joined by ", ". Keeping one separator lets SQL match a single tag, see
+// routes.TagFeed.
+func NormalizeTags(tag string) string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, t := range strings.Split(tag, ",") {
+		t = strings.Join(strings.Fields(t), " ")
+		if key := strings.ToLower(t); t != "" && !seen[key] {
+			seen[key] = true
+			tags = append(tags, t)
+		}
+	}
+	return strings.Join(tags, ", ")
+}
+
+func NewIndex(articles []models.Article) *Index {
+	index := &Index{byID: map[uint]int{}}
+	termCounts := make([]map[string]float64, len(articles))
+	documentFrequency := map[string]int{}
+
+	for i, article := range articles {
+		counts := map[string]float64{}
+		for _, token := range tokenize(article.Title) {
+			counts[token] += titleBoost
+		}
+		for _, token := range tokenize(markdown.PlainText(article.DescHTML)) {
+			counts[token]++
+		}
+		for term := range counts {
+			documentFrequency[term]++
+		}
+		termCounts[i] = counts
+	}
+
+	total := float64(len(articles))
+	for i, article := range articles {
+		vector := map[string]float64{}
+		var norm float64
+		for term, count := range termCounts[i] {
+			weight := (1 + math.Log(count)) * math.Log(1+total/float64(documentFrequency[term]))
+			vector[term] = weight
+			norm += weight * weight
+		}
+		index.byID[article.ID] = len(index.documents)
+		index.documents = append(index.documents, document{
+			id:     article.ID,
+			userID: article.UserID,
+			tags:   Tags(article.Tag),
+			vector: vector,
+			norm:   math.Sqrt(norm),
+		})
+	}
+	return index
+}
+
+func cosine(a, b document) float64 {
+	if a.norm == 0 || b.norm == 0 {
+		return 0
+	}
+	if len(a.vector) > len(b.vector) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, weight := range a.vector {
+		dot += weight * b.vector[term]
+	}
+	return dot / (a.norm * b.norm)
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for tag := range a {
+		if b[tag] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// Related returns up to limit articles most similar to articleID, best
+// first. Articles that share nothing with it are left out.
+func (index *Index) Related(articleID uint, limit int) []Scored {
+	position, ok := index.byID[articleID]
+	if !ok {
+		return nil
+	}
+	target := index.documents[position]
+
+	var scored []Scored
+	for _, candidate := range index.documents {
+		if candidate.id == target.id {
+			continue
+		}
+		score := textWeight*cosine(target, candidate) + tagWeight*jaccard(target.tags, candidate.tags)
+		if candidate.userID == target.userID {
+			score += authorWeight
+		}
+		if score > 0 {
+			scored = append(scored, Scored{ArticleID: candidate.id, Score: score})
+		}
+	}
+
+	return best(scored, limit)
+}
+
+// IDs lists every article in the index.
+func (index *Index) IDs() []uint {
+	ids := make([]uint, 0, len(index.documents))
+	for _, document := range index.documents {
+		ids = append(ids, document.id)
+	}
+	return ids
+}
@@ -0,0 +1,77 @@
+package recommend
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// TrendingWindow is how far back views and likes still count.
+	TrendingWindow = 7 * 24 * time.Hour
+
+	// trendingHalfLife halves the weight of a view or like every two days
+	// so yesterday's hit drops out in favour of today's.
+	trendingHalfLife = 2 * 24 * time.Hour
+
+	// likeWeight makes one like count as much as a handful of views, a like
+	// takes a logged in reader and a deliberate click.
+	likeWeight = 5
+)
+
+// DailyViews is the view count of every article read on one day.
+type DailyViews struct {
+	Day    time.Time
+	Counts map[uint]float64
+}
+
+// Like is when an article got liked.
+type Like struct {
+	ArticleID uint
+	CreatedAt time.Time
+}
+
+func RelatedKey(articleID uint) string {
+	return "related:" + strconv.FormatUint(uint64(articleID), 10)
+}
+
+func ViewsKey(day time.Time) string {
+	return "views:daily:" + day.UTC().Format("2006-01-02")
+}
+
+func decay(age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(trendingHalfLife))
+}
+
+// Trending scores articles by their views and likes inside the trending
+// window, more recent activity weighing more. Best first.
+func Trending(views []DailyViews, likes []Like, now time.Time, limit int) []Scored {
+	scores := map[uint]float64{}
+	for _, day := range views {
+		// A day's views are counted as if they happened at its middle.
+		age := now.Sub(day.Day.Add(12 * time.Hour))
+		if age > TrendingWindow {
+			continue
+		}
+		weight := decay(age)
+		for articleID, count := range day.Counts {
+			scores[articleID] += count * weight
+		}
+	}
+	for _, like := range likes {
+		age := now.Sub(like.CreatedAt)
+		if age > TrendingWindow {
+			continue
+		}
+		scores[like.ArticleID] += likeWeight * decay(age)
+	}
+
+	scored := make([]Scored, 0, len(scores))
+	for articleID, score := range scores {
+		scored = append(scored, Scored{ArticleID: articleID, Score: score})
+	}
+	return best(scored, limit)
+}
@@ -0,0 +1,247 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
+	"github.com/ArdhanaGusti/Golang_api/jobs"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/transfer"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ListUsers(c *gin.Context) {
+	users := []models.User{}
+	if err := h.db(c).Unscoped().Find(&users).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(200, response.NewAdminUsers(h.Config, users))
+}
+
+func (h *Handler) TrashArticles(c *gin.Context) {
+	items := []models.Article{}
+	if err := h.db(c).Unscoped().Preload("User").Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&items).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	expiresIn := jobs.TrashRetention(h.Config)
+	trash := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		trash = append(trash, gin.H{
+			"Article":   response.NewArticle(h.Config, item),
+			"DeletedAt": item.DeletedAt.Time,
+			"PurgeAt":   item.DeletedAt.Time.Add(expiresIn),
+		})
+	}
+
+	c.JSON(200, trash)
+}
+
+func (h *Handler) RestoreArticle(c *gin.Context) {
+	slug := c.Param("slug")
+	var item models.Article
+	if err := h.db(c).Unscoped().Where("slug = ? AND deleted_at IS NOT NULL", slug).First(&item).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article isn't in trash",
+		})
+		c.Abort()
+		return
+	}
+
+	if err := h.db(c).Unscoped().Model(&item).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	exist, _ := h.RDB.Exists("articles").Result()
+
+	if exist > 0 {
+		if err := h.RDB.Del("articles").Err(); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to delete redis because: " + err.Error(),
+			})
+			c.Abort()
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Article " + item.Title + " Restored Successfully",
+	})
+}
+
+func (h *Handler) PurgeArticle(c *gin.Context) {
+	slug := c.Param("slug")
+	var item models.Article
+	if err := h.db(c).Unscoped().Where("slug = ? AND deleted_at IS NOT NULL", slug).First(&item).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article isn't in trash",
+		})
+		c.Abort()
+		return
+	}
+
+	if err := jobs.PurgeArticle(h.db(c), h.Storage, &item); err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Article " + item.Title + " Purged Successfully",
+	})
+}
+
+func (h *Handler) ExportArticles(c *gin.Context) {
+	format := c.DefaultQuery("format", transfer.FormatJSONL)
+	if !transfer.IsValidFormat(format) {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    transfer.ErrUnknownFormat.Error(),
+		})
+		return
+	}
+
+	records, err := transfer.Export(h.db(c))
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := transfer.Encode(format, records, &buf); err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	filename := "articles-" + time.Now().Format("20060102-150405") + transfer.FileExtension(format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(200, transfer.ContentType(format), buf.Bytes())
+}
+
+func (h *Handler) ImportArticles(c *gin.Context) {
+	format := c.DefaultQuery("format", transfer.FormatJSONL)
+	if !transfer.IsValidFormat(format) {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    transfer.ErrUnknownFormat.Error(),
+		})
+		return
+	}
+
+	authorMap, err := transfer.ParseAuthorMap(c.Query("author_map"))
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	// The file may come as a multipart upload or as the raw request body.
+	maxSize := h.Config.Storage.ImportMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+	var source io.Reader = c.Request.Body
+	if fileHeader, err := c.FormFile("File"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(400, failed.FailedResponse{
+				StatusCode: 400,
+				Message:    err.Error(),
+			})
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+
+	data, err := io.ReadAll(source)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(413, failed.FailedResponse{
+			StatusCode: 413,
+			Message:    "Import is larger than " + strconv.FormatInt(maxSize, 10) + " bytes",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	records, err := transfer.Decode(format, data)
+	if err != nil {
+		c.JSON(422, failed.FailedResponse{
+			StatusCode: 422,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	report, err := transfer.Import(h.db(c), records, transfer.ImportOptions{
+		DryRun:        c.Query("dry_run") == "true",
+		Upsert:        c.Query("upsert") == "true",
+		AuthorMap:     authorMap,
+		DefaultAuthor: c.Query("default_author"),
+	})
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	if !report.DryRun && report.Created+report.Updated > 0 {
+		exist, _ := h.RDB.Exists("articles").Result()
+
+		if exist > 0 {
+			if err := h.RDB.Del("articles").Err(); err != nil {
+				c.JSON(500, failed.FailedResponse{
+					StatusCode: 500,
+					Message:    "Failed to delete redis because: " + err.Error(),
+				})
+				c.Abort()
+				return
+			}
+		}
+	}
+
+	c.JSON(200, report)
+}
@@ -3,20 +3,39 @@ package routes
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"time"
 
-	"github.com/ArdhanaGusti/Golang_api/config"
+	"github.com/ArdhanaGusti/Golang_api/analytics"
+	"github.com/ArdhanaGusti/Golang_api/handler/conditional"
 	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
+	"github.com/ArdhanaGusti/Golang_api/handler/slugs"
 	"github.com/ArdhanaGusti/Golang_api/handler/validation"
+	"github.com/ArdhanaGusti/Golang_api/middleware"
 	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/recommend"
 	"github.com/gin-gonic/gin"
-	"github.com/gosimple/slug"
+	"gorm.io/gorm"
 )
 
-func Home(c *gin.Context) {
+// readDB is where the read only handlers query: a replica, unless the
+// request writes or its user has to see what they just wrote.
+func (h *Handler) readDB(c *gin.Context) *gorm.DB {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return h.db(c)
+	}
+	return h.Reader(middleware.UserID(c, h.Config.Auth.JWTSecret)).WithContext(queryContext(c))
+}
+
+// publishedArticles is the base query of everything readers may list,
+// shared by Home and the feeds so they never disagree.
+func (h *Handler) publishedArticles(c *gin.Context) *gorm.DB {
+	return h.readDB(c).Preload("User").Preload("Attachments").Where("archived_at IS NULL")
+}
+
+func (h *Handler) Home(c *gin.Context) {
 	items := []models.Article{}
-	if err := config.DB.Preload("User").Find(&items).Error; err != nil {
+	if err := h.publishedArticles(c).Find(&items).Error; err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    err.Error(),
@@ -25,7 +44,8 @@ func Home(c *gin.Context) {
 		return
 	}
 
-	itemsJson, err := json.Marshal(items)
+	articles := response.NewArticles(h.Config, items)
+	itemsJson, err := json.Marshal(articles)
 	if err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
@@ -34,7 +54,7 @@ func Home(c *gin.Context) {
 		c.Abort()
 	}
 
-	if err := config.RDB.Set("articles", itemsJson, 0).Err(); err != nil {
+	if err := h.RDB.Set("articles", itemsJson, 0).Err(); err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    "Failed to set redis because: " + err.Error(),
@@ -42,13 +62,32 @@ func Home(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	c.JSON(200, items)
+	c.JSON(200, articles)
 }
 
-func GetArticle(c *gin.Context) {
+func (h *Handler) GetArticle(c *gin.Context) {
+	format := c.DefaultQuery("format", markdown.FormatMarkdown)
+	if !markdown.IsValidFormat(format) {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    "Format must be one of html, markdown or text",
+		})
+		return
+	}
+
 	slug := c.Param("slug")
 	var item models.Article
-	if err := config.DB.First(&item, "slug = ?", slug).Error; err != nil {
+	db := h.readDB(c)
+	if err := db.Preload("Attachments").First(&item, "slug = ?", slug).Error; err != nil {
+		if current, errs := slugs.Resolve(db, slug); errs == nil {
+			location := "/api/v1/article/" + current
+			if c.Request.URL.RawQuery != "" {
+				location += "?" + c.Request.URL.RawQuery
+			}
+			c.Redirect(http.StatusMovedPermanently, location)
+			return
+		}
+
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    err.Error(),
@@ -57,13 +96,28 @@ func GetArticle(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, item)
+	// A revalidation is still someone reading the article. Losing a view is
+	// not worth failing the read over, so errors are ignored.
+	analytics.RecordView(h.RDB, item.ID, analytics.Reader(c.ClientIP(), c.Request.UserAgent()), analytics.Referrer(c.Request.Referer()))
+	if conditional.NotModified(c, conditional.ArticleFormatETag(item, format), item.UpdatedAt) {
+		return
+	}
+
+	article := response.NewArticle(h.Config, item)
+	switch format {
+	case markdown.FormatHTML:
+		article.Desc = item.DescHTML
+	case markdown.FormatText:
+		article.Desc = markdown.PlainText(item.DescHTML)
+	}
+
+	c.JSON(200, article)
 }
 
 // func GetArticleTag(c *gin.Context) {
 // 	tag := c.Param("tag")
 // 	items := []models.Article{}
-// 	if err := config.DB.Where("tag LIKE ?", "%"+tag+"%").Find(&items).Error; err != nil {
+// 	if err := h.DB.Where("tag LIKE ?", "%"+tag+"%").Find(&items).Error; err != nil {
 // 		c.JSON(500, failed.FailedResponse{
 // 			StatusCode: 500,
 // 			Message:    err.Error(),
@@ -74,7 +128,7 @@ func GetArticle(c *gin.Context) {
 // 	})
 // }
 
-func PostArticle(c *gin.Context) {
+func (h *Handler) PostArticle(c *gin.Context) {
 	var articlePayload validation.CreateArticlePayload
 
 	if err := c.ShouldBind(&articlePayload); err != nil {
@@ -84,31 +138,39 @@ func PostArticle(c *gin.Context) {
 		return
 	}
 
-	slug := slug.Make(articlePayload.Title)
-	var oldItem models.Article
-	if err := config.DB.First(&oldItem, "slug = ?", slug).Error; err == nil {
-		slug = slug + strconv.FormatInt(time.Now().Unix(), 10)
+	descHTML, err := markdown.Render(articlePayload.Desc)
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    "Failed to render markdown because: " + err.Error(),
+		})
+		return
 	}
 
 	item := models.Article{
-		Title:  articlePayload.Title,
-		Desc:   articlePayload.Desc,
-		Tag:    articlePayload.Tag,
-		Slug:   slug,
-		UserID: uint(c.MustGet("jwt_user_id").(float64)),
+		Title:           articlePayload.Title,
+		Desc:            articlePayload.Desc,
+		DescHTML:        descHTML,
+		Tag:             recommend.NormalizeTags(articlePayload.Tag),
+		UserID:          uint(c.MustGet("jwt_user_id").(float64)),
+		Excerpt:         articlePayload.Excerpt,
+		MetaDescription: articlePayload.MetaDescription,
+		CanonicalURL:    articlePayload.CanonicalURL,
+		OGImage:         articlePayload.OGImage,
 	}
 
-	if err := config.DB.Create(&item).Error; err != nil {
+	if err := slugs.Create(h.db(c), &item); err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    err.Error(),
 		})
+		return
 	}
 
-	exist, _ := config.RDB.Exists("articles").Result()
+	exist, _ := h.RDB.Exists("articles").Result()
 
 	if exist > 0 {
-		if err := config.RDB.Del("articles").Err(); err != nil {
+		if err := h.RDB.Del("articles").Err(); err != nil {
 			c.JSON(500, failed.FailedResponse{
 				StatusCode: 500,
 				Message:    "Failed to delete redis because: " + err.Error(),
@@ -123,7 +185,7 @@ func PostArticle(c *gin.Context) {
 	})
 }
 
-func UpdateArticle(c *gin.Context) {
+func (h *Handler) UpdateArticle(c *gin.Context) {
 	var articlePayload validation.CreateArticlePayload
 
 	if err := c.ShouldBind(&articlePayload); err != nil {
@@ -133,12 +195,23 @@ func UpdateArticle(c *gin.Context) {
 		return
 	}
 
+	item, ok := h.findEditableArticle(c)
+	if !ok {
+		return
+	}
+
+	h.saveArticle(c, item, articlePayload)
+}
+
+// findEditableArticle loads the article of the :slug param and checks the
+// caller may write it, answering the request itself when not.
+func (h *Handler) findEditableArticle(c *gin.Context) (models.Article, bool) {
 	slug := c.Param("slug")
 	var item models.Article
-	if err := config.DB.First(&item, "slug = ?", slug).Error; err != nil {
+	if err := h.db(c).First(&item, "slug = ?", slug).Error; err != nil {
 		c.JSON(404, gin.H{"status": "error"})
 		c.Abort()
-		return
+		return item, false
 	}
 
 	if uint(c.MustGet("jwt_user_id").(float64)) != item.UserID {
@@ -147,26 +220,87 @@ func UpdateArticle(c *gin.Context) {
 			Message:    "Data is forbidden",
 		})
 		c.Abort()
+		return item, false
+	}
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !conditional.MatchStrong(ifMatch, conditional.ArticleETag(item)) {
+		c.JSON(412, failed.FailedResponse{
+			StatusCode: 412,
+			Message:    "Article was changed by someone else",
+		})
+		c.Abort()
+		return item, false
+	}
+
+	return item, true
+}
+
+// saveArticle writes a validated payload over item, shared by PUT and
+// PATCH so both keep the same version check, re-slugging and caching.
+func (h *Handler) saveArticle(c *gin.Context, item models.Article, articlePayload validation.CreateArticlePayload) {
+	slug := item.Slug
+	descHTML, err := markdown.Render(articlePayload.Desc)
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    "Failed to render markdown because: " + err.Error(),
+		})
 		return
 	}
 
 	updatedArticle := models.Article{
-		Title: articlePayload.Title,
-		Desc:  articlePayload.Desc,
-		Tag:   articlePayload.Tag,
+		Title:    articlePayload.Title,
+		Desc:     articlePayload.Desc,
+		DescHTML: descHTML,
+		Tag:      recommend.NormalizeTags(articlePayload.Tag),
 	}
 
-	if err := config.DB.Model(&item).Where("slug = ?", slug).Updates(updatedArticle).Error; err != nil {
+	// The version check makes the write atomic, if another editor saved in
+	// between our read and this update no row matches.
+	result := h.db(c).Model(&item).Where("slug = ? AND version = ?", slug, item.Version).Updates(map[string]interface{}{
+		"title":            updatedArticle.Title,
+		"desc":             updatedArticle.Desc,
+		"desc_html":        updatedArticle.DescHTML,
+		"tag":              updatedArticle.Tag,
+		"excerpt":          articlePayload.Excerpt,
+		"meta_description": articlePayload.MetaDescription,
+		"canonical_url":    articlePayload.CanonicalURL,
+		"og_image":         articlePayload.OGImage,
+		"version":          gorm.Expr("version + 1"),
+	})
+	if err := result.Error; err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    err.Error(),
 		})
+		return
 	}
+	if result.RowsAffected == 0 {
+		c.JSON(412, failed.FailedResponse{
+			StatusCode: 412,
+			Message:    "Article was changed by someone else",
+		})
+		return
+	}
+	item.Version++
 
-	exist, _ := config.RDB.Exists("articles").Result()
+	// Slugs stay stable by default, links shared earlier keep working
+	// without a redirect unless the author explicitly asks for a new one.
+	newSlug := ""
+	if c.Query("reslug") == "true" {
+		if newSlug, err = slugs.Reslug(h.db(c), &item, articlePayload.Title); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+			return
+		}
+	}
+
+	exist, _ := h.RDB.Exists("articles").Result()
 
 	if exist > 0 {
-		if err := config.RDB.Del("articles").Err(); err != nil {
+		if err := h.RDB.Del("articles").Err(); err != nil {
 			c.JSON(500, failed.FailedResponse{
 				StatusCode: 500,
 				Message:    "Failed to delete redis because: " + err.Error(),
@@ -176,34 +310,67 @@ func UpdateArticle(c *gin.Context) {
 		}
 	}
 
+	if newSlug != "" && newSlug != slug {
+		item.Version++
+	}
+	c.Header("ETag", conditional.ArticleETag(item))
+
+	if newSlug != "" {
+		c.JSON(200, gin.H{
+			"message": "Article " + updatedArticle.Title + " Updated Successfully",
+			"slug":    newSlug,
+		})
+		return
+	}
+
 	c.JSON(200, gin.H{
 		"message": "Article " + updatedArticle.Title + " Updated Successfully",
 	})
 }
 
-func DeleteArticle(c *gin.Context) {
+func (h *Handler) DeleteArticle(c *gin.Context) {
 	slug := c.Param("slug")
 	var item models.Article
-	if err := config.DB.Where("slug = ?", slug).First(&item).Error; err != nil {
-		c.JSON(500, failed.FailedResponse{
-			StatusCode: 500,
+	if err := h.db(c).Where("slug = ?", slug).First(&item).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
 			Message:    err.Error(),
 		})
+		c.Abort()
+		return
+	}
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !conditional.MatchStrong(ifMatch, conditional.ArticleETag(item)) {
+		c.JSON(412, failed.FailedResponse{
+			StatusCode: 412,
+			Message:    "Article was changed by someone else",
+		})
+		c.Abort()
+		return
 	}
 
 	var title = item.Title
 
-	if err := config.DB.Where("slug = ?", slug).Delete(&item).Error; err != nil {
+	result := h.db(c).Where("slug = ? AND version = ?", slug, item.Version).Delete(&item)
+	if err := result.Error; err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    err.Error(),
 		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(412, failed.FailedResponse{
+			StatusCode: 412,
+			Message:    "Article was changed by someone else",
+		})
+		return
 	}
 
-	exist, _ := config.RDB.Exists("articles").Result()
+	exist, _ := h.RDB.Exists("articles").Result()
 
 	if exist > 0 {
-		if err := config.RDB.Del("articles").Err(); err != nil {
+		if err := h.RDB.Del("articles").Err(); err != nil {
 			c.JSON(500, failed.FailedResponse{
 				StatusCode: 500,
 				Message:    "Failed to delete redis because: " + err.Error(),
@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/validation"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/recommend"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BulkOperationResult struct {
+	Index      int    `json:"Index"`
+	Op         string `json:"Op"`
+	Slug       string `json:"Slug"`
+	StatusCode int    `json:"StatusCode"`
+	Message    string `json:"Message"`
+}
+
+var errBulkRolledBack = errors.New("bulk operation rolled back")
+
+func applyBulkOperation(tx *gorm.DB, operation validation.BulkArticleOperation, userID uint, isAdmin bool) (int, error) {
+	var item models.Article
+	if err := tx.First(&item, "slug = ?", operation.Slug).Error; err != nil {
+		return 404, errors.New("Article don't exist")
+	}
+
+	// Same rules as the single article endpoints: only admins delete,
+	// owners (or admins) change their articles.
+	if operation.Op == "delete" && !isAdmin {
+		return 403, errors.New("You're not an admin")
+	}
+	if item.UserID != userID && !isAdmin {
+		return 403, errors.New("Data is forbidden")
+	}
+
+	var err error
+	switch operation.Op {
+	case "retag":
+		err = tx.Model(&item).Updates(map[string]interface{}{
+			"tag":     recommend.NormalizeTags(operation.Tag),
+			"version": gorm.Expr("version + 1"),
+		}).Error
+	case "archive":
+		err = tx.Model(&item).Updates(map[string]interface{}{
+			"archived_at": time.Now(),
+			"version":     gorm.Expr("version + 1"),
+		}).Error
+	case "unarchive":
+		err = tx.Model(&item).Updates(map[string]interface{}{
+			"archived_at": nil,
+			"version":     gorm.Expr("version + 1"),
+		}).Error
+	case "delete":
+		err = tx.Delete(&item).Error
+	}
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// BulkArticles runs many article operations in one request. With Atomic
+// set every operation shares one transaction and any failure rolls all of
+// them back, otherwise each one stands alone and the response reports
+// which succeeded. The articles cache is invalidated once at the end.
+func (h *Handler) BulkArticles(c *gin.Context) {
+	var bulkPayload validation.BulkArticlePayload
+
+	if err := c.ShouldBindJSON(&bulkPayload); err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	userID := uint(c.MustGet("jwt_user_id").(float64))
+	isAdmin := c.GetBool("jwt_user_role")
+	results := make([]BulkOperationResult, len(bulkPayload.Operations))
+	succeeded := 0
+
+	run := func(tx *gorm.DB, index int) error {
+		operation := bulkPayload.Operations[index]
+		statusCode, err := applyBulkOperation(tx, operation, userID, isAdmin)
+		results[index] = BulkOperationResult{
+			Index:      index,
+			Op:         operation.Op,
+			Slug:       operation.Slug,
+			StatusCode: statusCode,
+			Message:    "Success",
+		}
+		if err != nil {
+			results[index].Message = err.Error()
+			return err
+		}
+		succeeded++
+		return nil
+	}
+
+	if bulkPayload.Atomic {
+		err := h.db(c).Transaction(func(tx *gorm.DB) error {
+			for index := range bulkPayload.Operations {
+				if err := run(tx, index); err != nil {
+					return errBulkRolledBack
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			for index := range results {
+				if results[index].StatusCode == 200 {
+					results[index].StatusCode = 424
+					results[index].Message = errBulkRolledBack.Error()
+				} else if results[index].StatusCode == 0 {
+					results[index] = BulkOperationResult{
+						Index:      index,
+						Op:         bulkPayload.Operations[index].Op,
+						Slug:       bulkPayload.Operations[index].Slug,
+						StatusCode: 424,
+						Message:    "Not executed",
+					}
+				}
+			}
+			succeeded = 0
+		}
+	} else {
+		for index := range bulkPayload.Operations {
+			run(h.db(c), index)
+		}
+	}
+
+	if succeeded > 0 {
+		exist, _ := h.RDB.Exists("articles").Result()
+
+		if exist > 0 {
+			if err := h.RDB.Del("articles").Err(); err != nil {
+				c.JSON(500, failed.FailedResponse{
+					StatusCode: 500,
+					Message:    "Failed to delete redis because: " + err.Error(),
+				})
+				c.Abort()
+				return
+			}
+		}
+	}
+
+	statusCode := 200
+	if succeeded == 0 {
+		statusCode = 422
+	} else if succeeded < len(results) {
+		statusCode = 207
+	}
+
+	c.JSON(statusCode, gin.H{
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/validation"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// PatchArticle applies a JSON Merge Patch (RFC 7396) or JSON Patch
+// (RFC 6902) to the editable fields of an article. The patched document
+// has to pass the same validation as a full PUT before it's saved.
+func (h *Handler) PatchArticle(c *gin.Context) {
+	contentType := c.ContentType()
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType {
+		c.Header("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		c.JSON(415, failed.FailedResponse{
+			StatusCode: 415,
+			Message:    "Content-Type must be " + mergePatchContentType + " or " + jsonPatchContentType,
+		})
+		return
+	}
+
+	item, ok := h.findEditableArticle(c)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	original, err := json.Marshal(validation.CreateArticlePayload{
+		Title:           item.Title,
+		Desc:            item.Desc,
+		Tag:             item.Tag,
+		Excerpt:         item.Excerpt,
+		MetaDescription: item.MetaDescription,
+		CanonicalURL:    item.CanonicalURL,
+		OGImage:         item.OGImage,
+	})
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	var patched []byte
+	if contentType == mergePatchContentType {
+		patched, err = jsonpatch.MergePatch(original, patch)
+	} else {
+		var operations jsonpatch.Patch
+		if operations, err = jsonpatch.DecodePatch(patch); err == nil {
+			patched, err = operations.Apply(original)
+		}
+	}
+	if err != nil {
+		c.JSON(422, failed.FailedResponse{
+			StatusCode: 422,
+			Message:    "Failed to apply patch because: " + err.Error(),
+		})
+		return
+	}
+
+	// Only the payload fields are editable, a patch touching anything else
+	// is rejected instead of silently ignored.
+	var articlePayload validation.CreateArticlePayload
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&articlePayload); err != nil {
+		c.JSON(422, failed.FailedResponse{
+			StatusCode: 422,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&articlePayload); err != nil {
+		c.JSON(422, failed.FailedResponse{
+			StatusCode: 422,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	h.saveArticle(c, item, articlePayload)
+}
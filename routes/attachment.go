@@ -0,0 +1,331 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/imaging"
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
+	"github.com/ArdhanaGusti/Golang_api/jobs"
+	"github.com/ArdhanaGusti/Golang_api/middleware"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const thumbnailSize = 320
+
+var allowedAttachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+func (h *Handler) UploadAttachment(c *gin.Context) {
+	slug := c.Param("slug")
+	var article models.Article
+	if err := h.db(c).First(&article, "slug = ?", slug).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	if uint(c.MustGet("jwt_user_id").(float64)) != article.UserID {
+		c.JSON(403, failed.FailedResponse{
+			StatusCode: 403,
+			Message:    "Data is forbidden",
+		})
+		c.Abort()
+		return
+	}
+
+	maxSize := h.Config.Storage.UploadMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	fileHeader, err := c.FormFile("File")
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(413, failed.FailedResponse{
+			StatusCode: 413,
+			Message:    "File is larger than " + strconv.FormatInt(maxSize, 10) + " bytes",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil || int64(len(data)) > maxSize {
+		c.JSON(413, failed.FailedResponse{
+			StatusCode: 413,
+			Message:    "File is larger than " + strconv.FormatInt(maxSize, 10) + " bytes",
+		})
+		return
+	}
+
+	// Trust the bytes, not the client supplied Content-Type header.
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedAttachmentTypes[contentType]
+	if !ok {
+		c.JSON(415, failed.FailedResponse{
+			StatusCode: 415,
+			Message:    "File type " + contentType + " is not allowed",
+		})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	attachment := models.Attachment{
+		ArticleID:   article.ID,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Checksum:    checksum,
+		Key:         "attachments/" + checksum + ext,
+	}
+
+	if contentType != "application/pdf" {
+		img, err := imaging.Decode(data)
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			c.JSON(413, failed.FailedResponse{
+				StatusCode: 413,
+				Message:    "Image is larger than " + strconv.Itoa(imaging.MaxPixels) + " pixels",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(400, failed.FailedResponse{
+				StatusCode: 400,
+				Message:    "Failed to decode image because: " + err.Error(),
+			})
+			return
+		}
+		attachment.Width = img.Bounds().Dx()
+		attachment.Height = img.Bounds().Dy()
+
+		thumb, err := imaging.EncodeJPEG(imaging.Thumbnail(img, thumbnailSize))
+		if err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to make thumbnail because: " + err.Error(),
+			})
+			return
+		}
+		attachment.ThumbnailKey = "attachments/thumbs/" + checksum + ".jpg"
+		if err := h.Storage.Put(attachment.ThumbnailKey, bytes.NewReader(thumb), "image/jpeg"); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to store thumbnail because: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	// Without a row the blobs just stored are orphans, unless another
+	// attachment shares them.
+	if err := h.Storage.Put(attachment.Key, bytes.NewReader(data), contentType); err != nil {
+		jobs.ReleaseAttachments(h.db(c), h.Storage, []models.Attachment{attachment})
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    "Failed to store file because: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.db(c).Create(&attachment).Error; err != nil {
+		jobs.ReleaseAttachments(h.db(c), h.Storage, []models.Attachment{attachment})
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	// Attachments are part of the article representation, cached copies
+	// of it are stale now.
+	h.db(c).Model(&article).Update("version", gorm.Expr("version + 1"))
+
+	exist, _ := h.RDB.Exists("articles").Result()
+
+	if exist > 0 {
+		if err := h.RDB.Del("articles").Err(); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to delete redis because: " + err.Error(),
+			})
+			c.Abort()
+			return
+		}
+	}
+
+	c.JSON(200, response.NewAttachment(attachment))
+}
+
+func (h *Handler) GetAttachment(c *gin.Context) {
+	var attachment models.Attachment
+	if err := h.db(c).First(&attachment, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Attachment don't exist",
+		})
+		return
+	}
+
+	// Files of an article in the trash are only served to whoever may
+	// still see it there, its author and admins.
+	var article models.Article
+	if err := h.db(c).Unscoped().Select("id", "user_id", "deleted_at").First(&article, attachment.ArticleID).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Attachment don't exist",
+		})
+		return
+	}
+	trashed := article.DeletedAt.Valid
+	if trashed && middleware.UserID(c, h.Config.Auth.JWTSecret) != article.UserID && !middleware.IsAdminUser(c, h.Config.Auth.JWTSecret) {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Attachment don't exist",
+		})
+		return
+	}
+
+	key, contentType, etag := attachment.Key, attachment.ContentType, `"`+attachment.Checksum+`"`
+	if c.Query("size") == "thumb" {
+		if attachment.ThumbnailKey == "" {
+			c.JSON(404, failed.FailedResponse{
+				StatusCode: 404,
+				Message:    "Attachment has no thumbnail",
+			})
+			return
+		}
+		key, contentType, etag = attachment.ThumbnailKey, "image/jpeg", `"`+attachment.Checksum+`-thumb"`
+	}
+
+	// Keys are content addressed, so a blob behind a key never changes.
+	// Shared caches must not keep what only some users may see.
+	if trashed {
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	c.Header("ETag", etag)
+	c.Header("X-Content-Type-Options", "nosniff")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	blob, err := h.Storage.Get(key)
+	if err == storage.ErrNotFound {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Attachment file don't exist",
+		})
+		return
+	} else if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	defer blob.Close()
+
+	// FormatMediaType quotes the uploader's file name, or encodes it as
+	// filename* when it is not plain ASCII; it returns "" for names it can
+	// not express at all.
+	disposition := mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName})
+	if disposition == "" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", disposition)
+	c.DataFromReader(200, -1, contentType, blob, nil)
+}
+
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	var article models.Article
+	if err := h.db(c).First(&article, "slug = ?", c.Param("slug")).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article don't exist",
+		})
+		return
+	}
+
+	if uint(c.MustGet("jwt_user_id").(float64)) != article.UserID {
+		c.JSON(403, failed.FailedResponse{
+			StatusCode: 403,
+			Message:    "Data is forbidden",
+		})
+		return
+	}
+
+	var attachment models.Attachment
+	if err := h.db(c).First(&attachment, "id = ? AND article_id = ?", c.Param("id"), article.ID).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Attachment don't exist",
+		})
+		return
+	}
+
+	// Attachment rows are hard deleted, so every row left, including those
+	// of articles in the trash, is a reference jobs.ReleaseAttachments counts.
+	if err := h.db(c).Unscoped().Delete(&attachment).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	h.db(c).Model(&article).Update("version", gorm.Expr("version + 1"))
+
+	jobs.ReleaseAttachments(h.db(c), h.Storage, []models.Attachment{attachment})
+
+	exist, _ := h.RDB.Exists("articles").Result()
+
+	if exist > 0 {
+		if err := h.RDB.Del("articles").Err(); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to delete redis because: " + err.Error(),
+			})
+			c.Abort()
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Attachment " + attachment.FileName + " Deleted Successfully",
+	})
+}
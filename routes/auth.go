@@ -2,11 +2,10 @@ package routes
 
 import (
 	"net/http"
-	"os"
 	"time"
 
-	"github.com/ArdhanaGusti/Golang_api/config"
 	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
 	"github.com/ArdhanaGusti/Golang_api/handler/validation"
 	"github.com/ArdhanaGusti/Golang_api/models"
 	"github.com/gin-gonic/gin"
@@ -15,19 +14,19 @@ import (
 	"gopkg.in/danilopolani/gocialite.v1/structs"
 )
 
-func RedirectHandler(c *gin.Context) {
+func (h *Handler) RedirectHandler(c *gin.Context) {
 	provider := c.Param("provider")
 
 	providerSecrets := map[string]map[string]string{
 		"github": {
-			"clientID":     os.Getenv("CLIENT_ID_GH"),
-			"clientSecret": os.Getenv("CLIENT_SECRET_GH"),
-			"redirectURL":  os.Getenv("AUTH_REDIRECT_URL") + "/github/callback",
+			"clientID":     h.Config.Auth.GithubClientID,
+			"clientSecret": h.Config.Auth.GithubClientSecret,
+			"redirectURL":  h.Config.Auth.RedirectURL + "/github/callback",
 		},
 		"google": {
-			"clientID":     os.Getenv("CLIENT_ID_GO"),
-			"clientSecret": os.Getenv("CLIENT_SECRET_GO"),
-			"redirectURL":  os.Getenv("AUTH_REDIRECT_URL") + "/google/callback",
+			"clientID":     h.Config.Auth.GoogleClientID,
+			"clientSecret": h.Config.Auth.GoogleClientSecret,
+			"redirectURL":  h.Config.Auth.RedirectURL + "/google/callback",
 		},
 	}
 
@@ -38,7 +37,7 @@ func RedirectHandler(c *gin.Context) {
 
 	providerData := providerSecrets[provider]
 	actualScopes := providerScopes[provider]
-	authURL, err := config.Gocial.New().
+	authURL, err := h.Gocial.New().
 		Driver(provider).
 		Scopes(actualScopes).
 		Redirect(
@@ -60,12 +59,12 @@ func RedirectHandler(c *gin.Context) {
 	c.Redirect(http.StatusFound, authURL)
 }
 
-func CallbackHandler(c *gin.Context) {
+func (h *Handler) CallbackHandler(c *gin.Context) {
 	state := c.Query("state")
 	code := c.Query("code")
 	provider := c.Param("provider")
 
-	user, _, err := config.Gocial.Handle(state, code)
+	user, _, err := h.Gocial.Handle(state, code)
 	if err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
@@ -74,14 +73,14 @@ func CallbackHandler(c *gin.Context) {
 		return
 	}
 
-	newUser, ers := getOrRegisterUser(provider, (*structs.User)(user))
+	newUser, ers := h.getOrRegisterUser(c, provider, (*structs.User)(user))
 	if ers != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    ers.Error(),
 		})
 	}
-	jwtToken, ert := getToken(&newUser)
+	jwtToken, ert := h.getToken(&newUser)
 	if ert != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
@@ -89,16 +88,16 @@ func CallbackHandler(c *gin.Context) {
 		})
 	}
 	c.JSON(200, gin.H{
-		"data":    newUser,
+		"data":    response.NewSelfUser(h.Config, newUser),
 		"token":   jwtToken,
 		"message": "Berhasil",
 	})
 }
 
-func getOrRegisterUser(provider string, user *structs.User) (models.User, error) {
+func (h *Handler) getOrRegisterUser(c *gin.Context, provider string, user *structs.User) (models.User, error) {
 	var userData models.User
 
-	if err := config.DB.Where("provider = ? AND social_id = ?", provider, user.ID).First(&userData).Error; err != nil {
+	if err := h.db(c).Where("provider = ? AND social_id = ?", provider, user.ID).First(&userData).Error; err != nil {
 		return models.User{}, err
 	}
 
@@ -111,7 +110,7 @@ func getOrRegisterUser(provider string, user *structs.User) (models.User, error)
 			Provider: provider,
 			Avatar:   user.Avatar,
 		}
-		if err := config.DB.Create(&newUser).Error; err != nil {
+		if err := h.db(c).Create(&newUser).Error; err != nil {
 			return models.User{}, err
 		}
 		return newUser, nil
@@ -120,7 +119,7 @@ func getOrRegisterUser(provider string, user *structs.User) (models.User, error)
 	}
 }
 
-func getToken(user *models.User) (string, error) {
+func (h *Handler) getToken(user *models.User) (string, error) {
 	newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id":   user.ID,
 		"user_role": user.Role,
@@ -128,7 +127,7 @@ func getToken(user *models.User) (string, error) {
 		"iat":       time.Now().Unix(),
 	})
 
-	tokenString, err := newToken.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokenString, err := newToken.SignedString([]byte(h.Config.Auth.JWTSecret))
 	if err != nil {
 		return "", err
 	}
@@ -136,13 +135,13 @@ func getToken(user *models.User) (string, error) {
 	return tokenString, nil
 }
 
-func CheckToken(c *gin.Context) {
+func (h *Handler) CheckToken(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"message": "Berhasil",
 	})
 }
 
-func RegisterUser(c *gin.Context) {
+func (h *Handler) RegisterUser(c *gin.Context) {
 	var userPayload validation.RegisterUserPayload
 
 	if err := c.ShouldBind(&userPayload); err != nil {
@@ -154,7 +153,7 @@ func RegisterUser(c *gin.Context) {
 	}
 
 	var existedUser models.User
-	if err := config.DB.First(&existedUser, "email = ?", userPayload.Email).Error; err == nil {
+	if err := h.db(c).First(&existedUser, "LOWER(email) = LOWER(?)", userPayload.Email).Error; err == nil {
 		c.JSON(409, failed.FailedResponse{
 			StatusCode: 409,
 			Message:    "User is exist",
@@ -180,7 +179,7 @@ func RegisterUser(c *gin.Context) {
 		Password: string(hash),
 	}
 
-	if err := config.DB.Create(&newUser).Error; err != nil {
+	if err := h.db(c).Create(&newUser).Error; err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    err.Error(),
@@ -194,7 +193,7 @@ func RegisterUser(c *gin.Context) {
 	})
 }
 
-func LoginUser(c *gin.Context) {
+func (h *Handler) LoginUser(c *gin.Context) {
 	var userPayload validation.LoginUserPayload
 
 	if err := c.ShouldBind(&userPayload); err != nil {
@@ -206,7 +205,7 @@ func LoginUser(c *gin.Context) {
 	}
 
 	var existedUser models.User
-	if err := config.DB.First(&existedUser, "email = ?", userPayload.Email).Error; err != nil {
+	if err := h.db(c).First(&existedUser, "LOWER(email) = LOWER(?)", userPayload.Email).Error; err != nil {
 		c.JSON(404, failed.FailedResponse{
 			StatusCode: 404,
 			Message:    "User don't exist",
@@ -224,7 +223,7 @@ func LoginUser(c *gin.Context) {
 		return
 	}
 
-	jwtToken, ert := getToken(&existedUser)
+	jwtToken, ert := h.getToken(&existedUser)
 	if ert != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
@@ -239,9 +238,9 @@ func LoginUser(c *gin.Context) {
 	})
 }
 
-func ChangeRole(c *gin.Context) {
+func (h *Handler) ChangeRole(c *gin.Context) {
 	var existedUser models.User
-	if err := config.DB.First(&existedUser, "id = ?", uint(c.MustGet("jwt_user_id").(float64))).Error; err != nil {
+	if err := h.db(c).First(&existedUser, "id = ?", uint(c.MustGet("jwt_user_id").(float64))).Error; err != nil {
 		c.JSON(404, failed.FailedResponse{
 			StatusCode: 404,
 			Message:    "User don't exist",
@@ -264,7 +263,7 @@ func ChangeRole(c *gin.Context) {
 		newRole = true
 	}
 
-	if err := config.DB.Model(&existedUser).Where("id = ?", uint(c.MustGet("jwt_user_id").(float64))).Updates(models.User{Role: newRole}).Error; err != nil {
+	if err := h.db(c).Model(&existedUser).Where("id = ?", uint(c.MustGet("jwt_user_id").(float64))).Updates(models.User{Role: newRole}).Error; err != nil {
 		c.JSON(500, failed.FailedResponse{
 			StatusCode: 500,
 			Message:    err.Error(),
@@ -277,11 +276,11 @@ func ChangeRole(c *gin.Context) {
 	})
 }
 
-func GetProfile(c *gin.Context) {
+func (h *Handler) GetProfile(c *gin.Context) {
 	var user models.User
 	user_id := uint(c.MustGet("jwt_user_id").(float64))
 
-	if err := config.DB.Where("id = ?", user_id).Preload("Articles", "user_id = ?", user_id).Find(&user).Error; err != nil {
+	if err := h.db(c).Where("id = ?", user_id).Preload("Articles", "user_id = ?", user_id).Find(&user).Error; err != nil {
 		c.JSON(404, failed.FailedResponse{
 			StatusCode: 404,
 			Message:    err.Error(),
@@ -290,5 +289,5 @@ func GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, user)
+	c.JSON(200, response.NewSelfUser(h.Config, user))
 }
@@ -0,0 +1,235 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/imaging"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultAvatarSize = 128
+
+var avatarSizes = []int{32, 64, 128, 256}
+
+func avatarKey(checksum string, size int) string {
+	return "avatars/" + checksum + "/" + strconv.Itoa(size) + ".png"
+}
+
+// releaseAvatar removes the resized files of an avatar nobody uses anymore,
+// users uploading the same picture share them.
+func (h *Handler) releaseAvatar(c *gin.Context, checksum string) {
+	if checksum == "" {
+		return
+	}
+	var references int64
+	h.db(c).Unscoped().Model(&models.User{}).Where("avatar_checksum = ?", checksum).Count(&references)
+	if references > 0 {
+		return
+	}
+	for _, size := range avatarSizes {
+		h.Storage.Delete(avatarKey(checksum, size))
+	}
+}
+
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	var user models.User
+	if err := h.db(c).First(&user, "id = ?", uint(c.MustGet("jwt_user_id").(float64))).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "User don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	maxSize := h.Config.Storage.UploadMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	fileHeader, err := c.FormFile("Avatar")
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil || int64(len(data)) > maxSize {
+		c.JSON(413, failed.FailedResponse{
+			StatusCode: 413,
+			Message:    "File is larger than " + strconv.FormatInt(maxSize, 10) + " bytes",
+		})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := allowedAttachmentTypes[contentType]; !ok || contentType == "application/pdf" {
+		c.JSON(415, failed.FailedResponse{
+			StatusCode: 415,
+			Message:    "Avatar must be a jpeg, png, gif or webp image",
+		})
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		c.JSON(413, failed.FailedResponse{
+			StatusCode: 413,
+			Message:    "Avatar is larger than " + strconv.Itoa(imaging.MaxPixels) + " pixels",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    "Failed to decode image because: " + err.Error(),
+		})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	for _, size := range avatarSizes {
+		resized, err := imaging.EncodePNG(imaging.SquareCrop(img, size))
+		if err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to resize avatar because: " + err.Error(),
+			})
+			return
+		}
+		if err := h.Storage.Put(avatarKey(checksum, size), bytes.NewReader(resized), "image/png"); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to store avatar because: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	previous := user.AvatarChecksum
+	if err := h.db(c).Model(&user).Update("avatar_checksum", checksum).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if previous != checksum {
+		h.releaseAvatar(c, previous)
+	}
+
+	c.JSON(200, gin.H{
+		"avatar_url": models.AvatarPath(user.ID),
+		"message":    "Avatar Uploaded Successfully",
+	})
+}
+
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	userID := uint(c.MustGet("jwt_user_id").(float64))
+	var user models.User
+	if err := h.db(c).First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "User don't exist",
+		})
+		return
+	}
+	previous := user.AvatarChecksum
+	if err := h.db(c).Model(&user).Update("avatar_checksum", "").Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	h.releaseAvatar(c, previous)
+
+	c.JSON(200, gin.H{
+		"avatar_url": models.AvatarPath(userID),
+		"message":    "Avatar Deleted Successfully",
+	})
+}
+
+func (h *Handler) GetAvatar(c *gin.Context) {
+	var user models.User
+	if err := h.db(c).First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "User don't exist",
+		})
+		return
+	}
+
+	size := defaultAvatarSize
+	if requested, err := strconv.Atoi(c.Query("size")); err == nil {
+		for _, allowed := range avatarSizes {
+			if requested == allowed {
+				size = allowed
+			}
+		}
+	}
+
+	// The URL is stable while the picture behind it may change, so let
+	// clients revalidate with the ETag instead of caching forever.
+	c.Header("Cache-Control", "public, max-age=3600")
+
+	switch {
+	case user.AvatarChecksum != "":
+		etag := `"` + user.AvatarChecksum + "-" + strconv.Itoa(size) + `"`
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		blob, err := h.Storage.Get(avatarKey(user.AvatarChecksum, size))
+		if err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+			return
+		}
+		defer blob.Close()
+		c.DataFromReader(200, -1, "image/png", blob, nil)
+	case user.Avatar != "":
+		c.Redirect(http.StatusFound, user.Avatar)
+	default:
+		etag := `"identicon-` + strconv.FormatUint(uint64(user.ID), 10) + "-" + strconv.Itoa(size) + `"`
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		identicon, err := imaging.EncodePNG(imaging.Identicon(strconv.FormatUint(uint64(user.ID), 10), size))
+		if err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+			return
+		}
+		c.Data(200, "image/png", identicon)
+	}
+}
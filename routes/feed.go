@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/conditional"
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/feeds"
+	"gorm.io/gorm"
+)
+
+const maxFeedLimit = 100
+
+func (h *Handler) feedLimit(c *gin.Context) int {
+	limit := h.Config.Feed.ItemLimit
+	if requested, err := strconv.Atoi(c.Query("limit")); err == nil && requested > 0 {
+		limit = requested
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+	return limit
+}
+
+func (h *Handler) feedTitle() string {
+	return h.Config.Feed.Title
+}
+
+// serveFeed builds one feed out of the published articles matching scope
+// and renders it in the given format ("rss", "atom" or "json").
+func (h *Handler) serveFeed(c *gin.Context, format, title string, scope func(query *gorm.DB) *gorm.DB) {
+	items := []models.Article{}
+	query := scope(h.publishedArticles(c)).Order("created_at desc").Limit(h.feedLimit(c))
+	if err := query.Find(&items).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	// The feed only changes when an item in it is written or the set of
+	// items changes, both show up in ids and versions.
+	var updated time.Time
+	hash := sha256.New()
+	hash.Write([]byte(format + c.Request.URL.RawQuery))
+	for _, item := range items {
+		if item.UpdatedAt.After(updated) {
+			updated = item.UpdatedAt
+		}
+		hash.Write([]byte(strconv.FormatUint(uint64(item.ID), 10) + "-" + strconv.FormatUint(uint64(item.Version), 10) + ";"))
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+	if conditional.NotModified(c, etag, updated) {
+		return
+	}
+
+	selfURL := h.Config.AppURL + c.Request.URL.Path
+	feed := &feeds.Feed{
+		Title:       title,
+		Link:        &feeds.Link{Href: h.Config.AppURL},
+		Description: title,
+		Id:          selfURL,
+		Updated:     updated,
+	}
+	for _, item := range items {
+		link := h.Config.ArticleURL(item.Slug)
+		feed.Add(&feeds.Item{
+			Title:       item.Title,
+			Link:        &feeds.Link{Href: link},
+			Author:      &feeds.Author{Name: item.User.Fullname},
+			Description: markdown.PlainText(item.DescHTML),
+			Content:     item.DescHTML,
+			Id:          link,
+			Created:     item.CreatedAt,
+			Updated:     item.UpdatedAt,
+		})
+	}
+
+	var (
+		body        string
+		contentType string
+		err         error
+	)
+	switch format {
+	case "atom":
+		body, err = feed.ToAtom()
+		contentType = "application/atom+xml; charset=utf-8"
+	case "json":
+		body, err = feed.ToJSON()
+		contentType = "application/feed+json; charset=utf-8"
+	default:
+		body, err = feed.ToRss()
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	c.Data(200, contentType, []byte(body))
+}
+
+// Feed returns a handler for the site wide feed in the given format.
+func (h *Handler) Feed(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.serveFeed(c, format, h.feedTitle(), func(query *gorm.DB) *gorm.DB {
+			return query
+		})
+	}
+}
+
+func (h *Handler) TagFeed(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tag := c.Param("tag")
+		h.serveFeed(c, format, h.feedTitle()+" - "+tag, func(query *gorm.DB) *gorm.DB {
+			return hasTag(query, tag)
+		})
+	}
+}
+
+// likeEscaper escapes the LIKE wildcards, every driver accepts the explicit
+// ESCAPE '!' clause used by hasTag.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// hasTag keeps the articles with tag among their comma separated tags. Tags
+// are stored as recommend.NormalizeTags leaves them, so the tag is either the
+// whole column or bounded by ", ", compared case-insensitively like
+// recommend.Tags does.
+func hasTag(query *gorm.DB, tag string) *gorm.DB {
+	tag = likeEscaper.Replace(strings.ToLower(strings.Join(strings.Fields(tag), " ")))
+	return query.Where(
+		"(LOWER(tag) LIKE ? ESCAPE '!' OR LOWER(tag) LIKE ? ESCAPE '!' OR LOWER(tag) LIKE ? ESCAPE '!' OR LOWER(tag) LIKE ? ESCAPE '!')",
+		tag, tag+", %", "%, "+tag, "%, "+tag+", %",
+	)
+}
+
+func (h *Handler) AuthorFeed(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var author models.User
+		if err := h.readDB(c).First(&author, "id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(404, failed.FailedResponse{
+				StatusCode: 404,
+				Message:    "User don't exist",
+			})
+			return
+		}
+
+		h.serveFeed(c, format, h.feedTitle()+" - "+author.Fullname, func(query *gorm.DB) *gorm.DB {
+			return query.Where("user_id = ?", author.ID)
+		})
+	}
+}
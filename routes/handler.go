@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/app"
+	"github.com/ArdhanaGusti/Golang_api/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Handler serves the routes of one App, every handler is a method on it so
+// it reaches the database, Redis, ... of that App only.
+type Handler struct {
+	*app.App
+
+	// relatedRebuild guards rebuilding the related articles index on a
+	// cache miss, see rebuildRelated.
+	relatedRebuild   sync.Mutex
+	relatedRebuiltAt time.Time
+}
+
+func NewHandler(a *app.App) *Handler {
+	return &Handler{App: a}
+}
+
+// queryContext carries the request logger to the queries of a request, so
+// they log with its request ID. It is never canceled: a client hanging up
+// must not stop a write halfway.
+func queryContext(c *gin.Context) context.Context {
+	return context.WithoutCancel(c.Request.Context())
+}
+
+// db is the primary database for the queries of a request.
+func (h *Handler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(queryContext(c))
+}
+
+// logger is the logger of a request, see middleware.RequestLogger.
+func (h *Handler) logger(c *gin.Context) *slog.Logger {
+	return middleware.Logger(c)
+}
@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
+	"github.com/ArdhanaGusti/Golang_api/migrations"
+	"github.com/gin-gonic/gin"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// Healthz tells the process is up and serving, it checks nothing else so a
+// database outage never gets the API restarted.
+func (h *Handler) Healthz(c *gin.Context) {
+	c.JSON(200, response.HealthResponse{Status: response.StatusOK})
+}
+
+// Readyz tells whether this instance should get traffic: the database and
+// Redis answer, the schema is up to date and it is not shutting down.
+func (h *Handler) Readyz(c *gin.Context) {
+	checks := map[string]func(ctx context.Context) error{
+		"database": func(ctx context.Context) error {
+			sqlDB, err := h.DB.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+		"redis": func(ctx context.Context) error {
+			return h.RDB.WithContext(ctx).Ping().Err()
+		},
+		"migrations": func(ctx context.Context) error {
+			pending, err := migrations.Pending(h.DB.WithContext(ctx), h.Config.DB.Driver)
+			if err != nil {
+				return err
+			}
+			if pending > 0 {
+				return fmt.Errorf("%d migrations pending", pending)
+			}
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	type outcome struct {
+		name    string
+		err     error
+		latency time.Duration
+	}
+	// Buffered so a check answering after the deadline does not block.
+	outcomes := make(chan outcome, len(checks))
+	started := time.Now()
+	for name, check := range checks {
+		go func(name string, check func(ctx context.Context) error) {
+			start := time.Now()
+			err := check(ctx)
+			outcomes <- outcome{name: name, err: err, latency: time.Since(start)}
+		}(name, check)
+	}
+
+	// The probe is anonymous, the driver errors only go to the log.
+	result := response.HealthResponse{Status: response.StatusOK, Checks: map[string]response.CheckResponse{}}
+collect:
+	for range checks {
+		select {
+		case o := <-outcomes:
+			status := response.CheckResponse{
+				Status:    response.StatusOK,
+				LatencyMs: float64(o.latency.Microseconds()) / 1000,
+			}
+			if o.err != nil {
+				h.logger(c).Warn("Readiness check failed", "check", o.name, "error", o.err.Error())
+				status.Status = response.StatusUnavailable
+				status.Error = "failed"
+				result.Status = response.StatusUnavailable
+			}
+			result.Checks[o.name] = status
+		case <-ctx.Done():
+			break collect
+		}
+	}
+	for name := range checks {
+		if _, ok := result.Checks[name]; !ok {
+			h.logger(c).Warn("Readiness check timed out", "check", name, "error", ctx.Err().Error())
+			result.Checks[name] = response.CheckResponse{
+				Status:    response.StatusUnavailable,
+				LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+				Error:     "timed out",
+			}
+			result.Status = response.StatusUnavailable
+		}
+	}
+
+	if h.Draining.Load() {
+		result.Status = response.StatusUnavailable
+		result.Checks["shutdown"] = response.CheckResponse{Status: response.StatusUnavailable, Error: "shutting down"}
+	}
+
+	code := 200
+	if result.Status != response.StatusOK {
+		code = 503
+	}
+	c.JSON(code, result)
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) likeCount(c *gin.Context, articleID uint) int64 {
+	var count int64
+	h.db(c).Model(&models.ArticleLike{}).Where("article_id = ?", articleID).Count(&count)
+	return count
+}
+
+func (h *Handler) LikeArticle(c *gin.Context) {
+	var item models.Article
+	if err := h.db(c).Where("archived_at IS NULL").First(&item, "slug = ?", c.Param("slug")).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	like := models.ArticleLike{
+		ArticleID: item.ID,
+		UserID:    uint(c.MustGet("jwt_user_id").(float64)),
+	}
+	// Liking twice is not an error, the unique index keeps it to one like.
+	if err := h.db(c).Create(&like).Error; err != nil && !errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    "Failed to like article because: " + err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Article " + item.Title + " Liked Successfully",
+		"likes":   h.likeCount(c, item.ID),
+	})
+}
+
+func (h *Handler) UnlikeArticle(c *gin.Context) {
+	var item models.Article
+	if err := h.db(c).First(&item, "slug = ?", c.Param("slug")).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	userID := uint(c.MustGet("jwt_user_id").(float64))
+	if err := h.db(c).Where("article_id = ? AND user_id = ?", item.ID, userID).Delete(&models.ArticleLike{}).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    "Failed to unlike article because: " + err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Article " + item.Title + " Unliked Successfully",
+		"likes":   h.likeCount(c, item.ID),
+	})
+}
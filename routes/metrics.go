@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/gin-gonic/gin"
+)
+
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value float64
+}
+
+// Metrics exposes the database pool statistics and replica health in the
+// Prometheus text format. When METRICS_TOKEN is set the scraper has to send
+// it as a bearer token, without it the endpoint is public and main warns
+// about it on start.
+func (h *Handler) Metrics(c *gin.Context) {
+	if token := h.Config.MetricsToken; token != "" {
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.JSON(401, failed.FailedResponse{
+				StatusCode: 401,
+				Message:    "Unauthorized",
+			})
+			return
+		}
+	}
+
+	sqlDB, err := h.DB.DB()
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	stats := sqlDB.Stats()
+
+	metrics := []metric{
+		{"db_max_open_connections", "gauge", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections)},
+		{"db_open_connections", "gauge", "Established connections, both in use and idle.", float64(stats.OpenConnections)},
+		{"db_in_use_connections", "gauge", "Connections currently in use.", float64(stats.InUse)},
+		{"db_idle_connections", "gauge", "Idle connections.", float64(stats.Idle)},
+		{"db_wait_count_total", "counter", "Connections waited for.", float64(stats.WaitCount)},
+		{"db_wait_duration_seconds_total", "counter", "Time blocked waiting for a connection.", stats.WaitDuration.Seconds()},
+		{"db_max_idle_closed_total", "counter", "Connections closed due to SetMaxIdleConns.", float64(stats.MaxIdleClosed)},
+		{"db_max_idle_time_closed_total", "counter", "Connections closed due to SetConnMaxIdleTime.", float64(stats.MaxIdleTimeClosed)},
+		{"db_max_lifetime_closed_total", "counter", "Connections closed due to SetConnMaxLifetime.", float64(stats.MaxLifetimeClosed)},
+		{"db_replicas", "gauge", "Read replicas configured.", float64(h.Replicas.Len())},
+		{"db_healthy_replicas", "gauge", "Read replicas answering health checks.", float64(h.Replicas.Healthy())},
+	}
+
+	var body strings.Builder
+	for _, m := range metrics {
+		name := "golang_api_" + m.name
+		fmt.Fprintf(&body, "# HELP %s %s\n# TYPE %s %s\n%s{driver=%q} %g\n", name, m.help, name, m.kind, name, h.Config.DB.Driver, m.value)
+	}
+	c.Data(200, "text/plain; version=0.0.4; charset=utf-8", []byte(body.String()))
+}
@@ -0,0 +1,343 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/mail"
+	"github.com/ArdhanaGusti/Golang_api/handler/validation"
+	"github.com/ArdhanaGusti/Golang_api/jobs"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerifyTTL  = 24 * time.Hour
+	deletedUsername = "deleted"
+)
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var profilePayload validation.UpdateProfilePayload
+
+	if err := c.ShouldBind(&profilePayload); err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := h.db(c).First(&user, "id = ?", uint(c.MustGet("jwt_user_id").(float64))).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "User don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if profilePayload.Username != nil {
+		var existedUser models.User
+		if err := h.db(c).First(&existedUser, "LOWER(username) = LOWER(?) AND id <> ?", *profilePayload.Username, user.ID).Error; err == nil {
+			c.JSON(409, failed.FailedResponse{
+				StatusCode: 409,
+				Message:    "Username is used by another user",
+			})
+			return
+		}
+		updates["username"] = *profilePayload.Username
+	}
+	if profilePayload.Fullname != nil {
+		updates["fullname"] = *profilePayload.Fullname
+	}
+
+	// A new email only replaces the current one after the owner clicks
+	// the link we send to it.
+	var verifyToken string
+	if profilePayload.Email != nil && *profilePayload.Email != user.Email {
+		var existedUser models.User
+		if err := h.db(c).First(&existedUser, "LOWER(email) = LOWER(?)", *profilePayload.Email).Error; err == nil {
+			c.JSON(409, failed.FailedResponse{
+				StatusCode: 409,
+				Message:    "Email is used by another user",
+			})
+			return
+		}
+
+		token, err := newToken()
+		if err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+			return
+		}
+		verifyToken = token
+		expiresAt := time.Now().Add(emailVerifyTTL)
+		updates["pending_email"] = *profilePayload.Email
+		updates["email_verify_token"] = hashToken(token)
+		updates["email_verify_expires_at"] = &expiresAt
+	}
+
+	if len(updates) == 0 {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    "Nothing to update",
+		})
+		return
+	}
+
+	if err := h.db(c).Model(&user).Updates(updates).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	if verifyToken != "" {
+		link := h.Config.AppURL + "/api/v1/auth/verify-email?token=" + url.QueryEscape(verifyToken)
+		if err := mail.Send(h.logger(c), h.Config.Mail, *profilePayload.Email, "Verify your new email", "Open this link to confirm your new email:\n"+link); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to send verification email because: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, gin.H{
+			"message": "Profile Updated Successfully, check " + *profilePayload.Email + " to verify it",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Profile Updated Successfully",
+	})
+}
+
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    "Token can't be empty",
+		})
+		return
+	}
+
+	var user models.User
+	if err := h.db(c).First(&user, "email_verify_token = ?", hashToken(token)).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Token is invalid",
+		})
+		return
+	}
+
+	if user.EmailVerifyExpiresAt == nil || time.Now().After(*user.EmailVerifyExpiresAt) {
+		c.JSON(410, failed.FailedResponse{
+			StatusCode: 410,
+			Message:    "Token is expired",
+		})
+		return
+	}
+
+	var existedUser models.User
+	if err := h.db(c).First(&existedUser, "LOWER(email) = LOWER(?)", user.PendingEmail).Error; err == nil {
+		c.JSON(409, failed.FailedResponse{
+			StatusCode: 409,
+			Message:    "Email is used by another user",
+		})
+		return
+	}
+
+	if err := h.db(c).Model(&user).Updates(map[string]interface{}{
+		"email":                   user.PendingEmail,
+		"pending_email":           "",
+		"email_verify_token":      "",
+		"email_verify_expires_at": nil,
+	}).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Email changed to " + user.PendingEmail + " Successfully",
+	})
+}
+
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var passwordPayload validation.ChangePasswordPayload
+
+	if err := c.ShouldBind(&passwordPayload); err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := h.db(c).First(&user, "id = ?", uint(c.MustGet("jwt_user_id").(float64))).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "User don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	// Social login users never had a password, they may set a first one.
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(passwordPayload.CurrentPassword)); err != nil {
+			c.JSON(403, failed.FailedResponse{
+				StatusCode: 403,
+				Message:    "Current password is wrong",
+			})
+			return
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(passwordPayload.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    "Hashing is failed",
+		})
+		return
+	}
+
+	if err := h.db(c).Model(&user).Update("password", string(hash)).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Password Changed Successfully",
+	})
+}
+
+// deletedUser returns the shared placeholder author that anonymized
+// articles are moved to.
+func deletedUser(tx *gorm.DB) (models.User, error) {
+	var ghost models.User
+	err := tx.Where(models.User{Username: deletedUsername, Provider: deletedUsername}).
+		Attrs(models.User{Fullname: "Deleted User"}).
+		FirstOrCreate(&ghost).Error
+	return ghost, err
+}
+
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	var deletePayload validation.DeleteAccountPayload
+
+	if err := c.ShouldBind(&deletePayload); err != nil {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := h.db(c).First(&user, "id = ?", uint(c.MustGet("jwt_user_id").(float64))).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "User don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(deletePayload.Password)); err != nil {
+			c.JSON(403, failed.FailedResponse{
+				StatusCode: 403,
+				Message:    "Password is wrong",
+			})
+			return
+		}
+	}
+
+	// The cascade only removes rows, the files of the attachments it takes
+	// are released once it is done.
+	var attachments []models.Attachment
+	if deletePayload.Mode != "anonymize" {
+		articles := h.db(c).Unscoped().Model(&models.Article{}).Select("id").Where("user_id = ?", user.ID)
+		if err := h.db(c).Unscoped().Where("article_id IN (?)", articles).Find(&attachments).Error; err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+			return
+		}
+	}
+
+	// The user row is removed for real so the OnDelete:CASCADE constraint
+	// takes the remaining articles (and their attachments) with it.
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if deletePayload.Mode == "anonymize" {
+			ghost, err := deletedUser(tx)
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.Article{}).Where("user_id = ?", user.ID).Update("user_id", ghost.ID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&user).Error
+	})
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	jobs.ReleaseAttachments(h.db(c), h.Storage, attachments)
+	h.releaseAvatar(c, user.AvatarChecksum)
+
+	exist, _ := h.RDB.Exists("articles").Result()
+
+	if exist > 0 {
+		if err := h.RDB.Del("articles").Err(); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to delete redis because: " + err.Error(),
+			})
+			c.Abort()
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Account " + user.Email + " Deleted Successfully",
+	})
+}
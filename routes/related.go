@@ -0,0 +1,200 @@
+package routes
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
+	"github.com/ArdhanaGusti/Golang_api/jobs"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/recommend"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRelatedLimit  = 5
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+
+	trendingKey = "trending"
+	trendingTTL = 5 * time.Minute
+	relatedTTL  = time.Hour
+	// relatedRebuildGap is the least time between two rebuilds of the
+	// related articles index on cache misses.
+	relatedRebuildGap = time.Minute
+)
+
+func limitParam(c *gin.Context, fallback, max int) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = fallback
+	}
+	if limit > max {
+		limit = max
+	}
+	return limit
+}
+
+// scoredArticles loads the published articles in scored, keeping its order.
+// Articles deleted or archived since they were scored are skipped.
+func (h *Handler) scoredArticles(c *gin.Context, scored []recommend.Scored) ([]response.ArticleResponse, error) {
+	ids := make([]uint, 0, len(scored))
+	for _, s := range scored {
+		ids = append(ids, s.ArticleID)
+	}
+
+	items := []models.Article{}
+	if len(ids) > 0 {
+		if err := h.publishedArticles(c).Where("id IN ?", ids).Find(&items).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	byID := map[uint]models.Article{}
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	articles := []response.ArticleResponse{}
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			articles = append(articles, response.NewArticle(h.Config, item))
+		}
+	}
+	return articles, nil
+}
+
+func (h *Handler) cachedScores(key string) ([]recommend.Scored, bool) {
+	cached, err := h.RDB.Get(key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	var scored []recommend.Scored
+	if err := json.Unmarshal(cached, &scored); err != nil {
+		return nil, false
+	}
+	return scored, true
+}
+
+// rebuildRelated scores every article again when one is missing from the
+// cache, e.g. it was published after the last refresh. The endpoint is
+// public, so a miss must not cost a full scan each time: one rebuild runs
+// at a time and at most one per relatedRebuildGap, other misses get nothing
+// and wait for StartRelatedRefresh.
+func (h *Handler) rebuildRelated(articleID uint) ([]recommend.Scored, error) {
+	if !h.relatedRebuild.TryLock() {
+		return nil, nil
+	}
+	defer h.relatedRebuild.Unlock()
+	if time.Since(h.relatedRebuiltAt) < relatedRebuildGap {
+		return nil, nil
+	}
+	h.relatedRebuiltAt = time.Now()
+
+	if _, err := jobs.RefreshRelated(h.App, relatedTTL); err != nil {
+		return nil, err
+	}
+	related, _ := h.cachedScores(recommend.RelatedKey(articleID))
+	return related, nil
+}
+
+// RelatedArticles lists the articles most similar to one article. They are
+// precomputed in the background, see rebuildRelated for an article the job
+// has not seen yet.
+func (h *Handler) RelatedArticles(c *gin.Context) {
+	var item models.Article
+	if err := h.readDB(c).Select("id").Where("archived_at IS NULL").First(&item, "slug = ?", c.Param("slug")).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article don't exist",
+		})
+		return
+	}
+
+	related, ok := h.cachedScores(recommend.RelatedKey(item.ID))
+	if !ok {
+		var err error
+		if related, err = h.rebuildRelated(item.ID); err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+			return
+		}
+	}
+
+	limit := limitParam(c, defaultRelatedLimit, recommend.MaxRelated)
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	articles, err := h.scoredArticles(c, related)
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	c.JSON(200, articles)
+}
+
+func (h *Handler) trendingScores() ([]recommend.Scored, error) {
+	if scored, ok := h.cachedScores(trendingKey); ok {
+		return scored, nil
+	}
+
+	now := time.Now()
+	var views []recommend.DailyViews
+	for day := now.Add(-recommend.TrendingWindow); !day.After(now); day = day.Add(24 * time.Hour) {
+		members, err := h.RDB.ZRevRangeWithScores(recommend.ViewsKey(day), 0, 999).Result()
+		if err != nil {
+			return nil, err
+		}
+		counts := map[uint]float64{}
+		for _, member := range members {
+			id, err := strconv.ParseUint(member.Member.(string), 10, 64)
+			if err != nil {
+				continue
+			}
+			counts[uint(id)] = member.Score
+		}
+		views = append(views, recommend.DailyViews{Day: day.UTC().Truncate(24 * time.Hour), Counts: counts})
+	}
+
+	var likes []recommend.Like
+	if err := h.Reader(0).Model(&models.ArticleLike{}).Select("article_id", "created_at").Where("created_at >= ?", now.Add(-recommend.TrendingWindow)).Find(&likes).Error; err != nil {
+		return nil, err
+	}
+
+	scored := recommend.Trending(views, likes, now, maxTrendingLimit)
+	if scoredJson, err := json.Marshal(scored); err == nil {
+		h.RDB.Set(trendingKey, scoredJson, trendingTTL)
+	}
+	return scored, nil
+}
+
+// TrendingArticles lists the articles read and liked the most lately.
+func (h *Handler) TrendingArticles(c *gin.Context) {
+	scored, err := h.trendingScores()
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	if limit := limitParam(c, defaultTrendingLimit, maxTrendingLimit); len(scored) > limit {
+		scored = scored[:limit]
+	}
+	articles, err := h.scoredArticles(c, scored)
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	c.JSON(200, articles)
+}
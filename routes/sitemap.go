@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/conditional"
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemapStats struct {
+	Count     int64
+	UpdatedAt time.Time
+}
+
+func (h *Handler) sitemapArticles() (sitemapStats, error) {
+	var stats sitemapStats
+	// Crawlers are anonymous, a replica always does.
+	db := h.Reader(0)
+	published := db.Model(&models.Article{}).Where("archived_at IS NULL")
+	if err := published.Count(&stats.Count).Error; err != nil {
+		return stats, err
+	}
+
+	var latest models.Article
+	if stats.Count > 0 {
+		if err := db.Select("updated_at").Where("archived_at IS NULL").Order("updated_at desc").First(&latest).Error; err != nil {
+			return stats, err
+		}
+	}
+	stats.UpdatedAt = latest.UpdatedAt
+	return stats, nil
+}
+
+func writeSitemapXML(c *gin.Context, value interface{}) {
+	body, err := xml.MarshalIndent(value, "", "  ")
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	c.Data(200, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+func sitemapETag(stats sitemapStats, page int) string {
+	return `"sitemap-` + strconv.Itoa(page) + "-" + strconv.FormatInt(stats.Count, 10) + "-" + strconv.FormatInt(stats.UpdatedAt.Unix(), 10) + `"`
+}
+
+// Sitemap serves /sitemap.xml. Up to SITEMAP_PAGE_SIZE articles it is a
+// plain urlset, beyond that it turns into an index of /sitemaps/N.xml pages.
+func (h *Handler) Sitemap(c *gin.Context) {
+	stats, err := h.sitemapArticles()
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if conditional.NotModified(c, sitemapETag(stats, 0), stats.UpdatedAt) {
+		return
+	}
+
+	pageSize := int64(h.Config.Feed.SitemapPageSize)
+	if stats.Count <= pageSize {
+		h.serveSitemapPage(c, 1)
+		return
+	}
+
+	index := sitemapIndex{Xmlns: sitemapNamespace}
+	pages := int((stats.Count + pageSize - 1) / pageSize)
+	for page := 1; page <= pages; page++ {
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{
+			Loc:     h.Config.AppURL + "/sitemaps/" + strconv.Itoa(page) + ".xml",
+			LastMod: stats.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeSitemapXML(c, index)
+}
+
+func (h *Handler) SitemapPage(c *gin.Context) {
+	page, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".xml"))
+	if err != nil || page < 1 {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Sitemap don't exist",
+		})
+		return
+	}
+
+	stats, err := h.sitemapArticles()
+	if err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if int64(page-1)*int64(h.Config.Feed.SitemapPageSize) >= stats.Count && page != 1 {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Sitemap don't exist",
+		})
+		return
+	}
+	if conditional.NotModified(c, sitemapETag(stats, page), stats.UpdatedAt) {
+		return
+	}
+
+	h.serveSitemapPage(c, page)
+}
+
+func (h *Handler) serveSitemapPage(c *gin.Context, page int) {
+	pageSize := h.Config.Feed.SitemapPageSize
+	var items []models.Article
+	if err := h.readDB(c).Select("id", "slug", "canonical_url", "updated_at").
+		Where("archived_at IS NULL").
+		Order("id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&items).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	urlSet := sitemapURLSet{Xmlns: sitemapNamespace, URLs: make([]sitemapURL, 0, len(items))}
+	for _, item := range items {
+		loc := item.CanonicalURL
+		if loc == "" {
+			loc = h.Config.ArticleURL(item.Slug)
+		}
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:     loc,
+			LastMod: item.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeSitemapXML(c, urlSet)
+}
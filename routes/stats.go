@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/analytics"
+	"github.com/ArdhanaGusti/Golang_api/handler/failed"
+	"github.com/ArdhanaGusti/Golang_api/handler/response"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+)
+
+func statsRange(c *gin.Context) (time.Time, time.Time, string) {
+	to := analytics.Day(time.Now())
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(analytics.DateLayout, value)
+		if err != nil {
+			return defaultStatsFrom(to), to, "To must be a date formatted as YYYY-MM-DD"
+		}
+		to = parsed
+	}
+
+	start := defaultStatsFrom(to)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(analytics.DateLayout, value)
+		if err != nil {
+			return start, to, "From must be a date formatted as YYYY-MM-DD"
+		}
+		start = parsed
+	}
+
+	if start.After(to) {
+		return start, to, "From must not be after To"
+	}
+	if days := int(to.Sub(start).Hours()/24) + 1; days > maxStatsDays {
+		return start, to, "Date range must not be longer than " + strconv.Itoa(maxStatsDays) + " days"
+	}
+	return start, to, ""
+}
+
+func defaultStatsFrom(to time.Time) time.Time {
+	return to.AddDate(0, 0, -(defaultStatsDays - 1))
+}
+
+// ArticleStats reports the reads of an article to its author (or an admin)
+// over ?from= and ?to=, the last 30 days by default. Reads not flushed to
+// the database yet are added from Redis.
+func (h *Handler) ArticleStats(c *gin.Context) {
+	var item models.Article
+	if err := h.db(c).First(&item, "slug = ?", c.Param("slug")).Error; err != nil {
+		c.JSON(404, failed.FailedResponse{
+			StatusCode: 404,
+			Message:    "Article don't exist",
+		})
+		c.Abort()
+		return
+	}
+
+	if uint(c.MustGet("jwt_user_id").(float64)) != item.UserID && !c.GetBool("jwt_user_role") {
+		c.JSON(403, failed.FailedResponse{
+			StatusCode: 403,
+			Message:    "Data is forbidden",
+		})
+		c.Abort()
+		return
+	}
+
+	start, to, message := statsRange(c)
+	if message != "" {
+		c.JSON(400, failed.FailedResponse{
+			StatusCode: 400,
+			Message:    message,
+		})
+		return
+	}
+	fromDay, toDay := start.Format(analytics.DateLayout), to.Format(analytics.DateLayout)
+
+	var views []models.ArticleView
+	if err := h.db(c).Where("article_id = ? AND day BETWEEN ? AND ?", item.ID, fromDay, toDay).Find(&views).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+	var referrers []models.ArticleReferrer
+	if err := h.db(c).Where("article_id = ? AND day BETWEEN ? AND ?", item.ID, fromDay, toDay).Find(&referrers).Error; err != nil {
+		c.JSON(500, failed.FailedResponse{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	days := map[string]*response.DailyStatsResponse{}
+	stats := response.ArticleStatsResponse{From: fromDay, To: toDay}
+	for day := start; !day.After(to); day = day.AddDate(0, 0, 1) {
+		stats.Days = append(stats.Days, response.DailyStatsResponse{Day: day.Format(analytics.DateLayout)})
+	}
+	for i := range stats.Days {
+		days[stats.Days[i].Day] = &stats.Days[i]
+	}
+	for _, view := range views {
+		if day, ok := days[view.Day]; ok {
+			day.Views += view.Views
+			day.UniqueReaders = view.UniqueReaders
+		}
+	}
+	referrerViews := map[string]uint64{}
+	for _, referrer := range referrers {
+		referrerViews[referrer.Referrer] += referrer.Views
+	}
+
+	// Only today and yesterday can still have reads buffered in Redis.
+	today := analytics.Day(time.Now())
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		daily, ok := days[day.Format(analytics.DateLayout)]
+		if !ok {
+			continue
+		}
+		pending, err := analytics.PendingViews(h.RDB, item.ID, day)
+		if err != nil {
+			c.JSON(500, failed.FailedResponse{
+				StatusCode: 500,
+				Message:    "Failed to get redis because: " + err.Error(),
+			})
+			return
+		}
+		daily.Views += uint64(pending.Views)
+		if uint64(pending.UniqueReaders) > daily.UniqueReaders {
+			daily.UniqueReaders = uint64(pending.UniqueReaders)
+		}
+		for referrer, count := range pending.Referrers {
+			referrerViews[referrer] += uint64(count)
+		}
+	}
+
+	for _, day := range stats.Days {
+		stats.Views += day.Views
+		stats.DailyUniqueReadersSum += day.UniqueReaders
+	}
+	stats.Referrers = []response.ReferrerStatsResponse{}
+	for referrer, count := range referrerViews {
+		stats.Referrers = append(stats.Referrers, response.ReferrerStatsResponse{Referrer: referrer, Views: count})
+	}
+	sort.Slice(stats.Referrers, func(i, j int) bool {
+		if stats.Referrers[i].Views == stats.Referrers[j].Views {
+			return stats.Referrers[i].Referrer < stats.Referrers[j].Referrer
+		}
+		return stats.Referrers[i].Views > stats.Referrers[j].Views
+	})
+
+	c.JSON(200, stats)
+}
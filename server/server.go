@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/config"
+)
+
+// New builds the HTTP server of cfg around handler. With TLS_CERT_FILE and
+// TLS_KEY_FILE set it serves HTTPS and picks up renewed certificates
+// without a restart.
+func New(cfg config.ServerConfig, handler http.Handler, logger *slog.Logger) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		// Failed handshakes and the like, which never reach the router.
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	if cfg.TLSCertFile != "" {
+		certificates, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, logger)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certificates.GetCertificate,
+		}
+	}
+	return srv, nil
+}
+
+// Run serves until ctx is done. It then calls drain, keeps serving for
+// SERVER_SHUTDOWN_DELAY so load balancers notice the instance is not ready
+// anymore, stops taking connections and waits up to
+// SERVER_SHUTDOWN_TIMEOUT for the requests in flight to finish.
+func Run(ctx context.Context, srv *http.Server, cfg config.ServerConfig, drain func()) error {
+	errs := make(chan error, 1)
+	go func() {
+		var err error
+		if srv.TLSConfig != nil {
+			// The certificate comes from GetCertificate.
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	drain()
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(cfg.ShutdownDelay):
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
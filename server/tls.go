@@ -0,0 +1,80 @@
+package server
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval is how often a handshake looks at the files for a
+// renewed certificate.
+const certCheckInterval = 10 * time.Second
+
+// CertReloader serves a certificate from disk and loads it again once the
+// files change, e.g. after certbot renewed it.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func NewCertReloader(certFile, keyFile string, logger *slog.Logger) (*CertReloader, error) {
+	reloader := &CertReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	modTime, err := reloader.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := reloader.load(modTime); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (reloader *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{reloader.certFile, reloader.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (reloader *CertReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return err
+	}
+	reloader.cert = &cert
+	reloader.modTime = modTime
+	return nil
+}
+
+// GetCertificate is the tls.Config hook. A pair that fails to load, say one
+// caught halfway through being written, leaves the current certificate in
+// place until the next check.
+func (reloader *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	if time.Since(reloader.checked) >= certCheckInterval {
+		reloader.checked = time.Now()
+		if modTime, err := reloader.latestModTime(); err == nil && !modTime.Equal(reloader.modTime) {
+			if err := reloader.load(modTime); err != nil {
+				reloader.logger.Error("Failed to reload the TLS certificate", "error", err.Error())
+			}
+		}
+	}
+	return reloader.cert, nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCert(t *testing.T, certFile, keyFile, name string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	assert.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	assert.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func commonName(t *testing.T, reloader *CertReloader) string {
+	cert, err := reloader.GetCertificate(nil)
+	assert.NoError(t, err)
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, "old", time.Now().Add(-time.Hour))
+
+	reloader, err := NewCertReloader(certFile, keyFile, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, "old", commonName(t, reloader))
+
+	writeCert(t, certFile, keyFile, "renewed", time.Now())
+	// Renewals are only looked for every certCheckInterval.
+	assert.Equal(t, "old", commonName(t, reloader))
+	reloader.checked = time.Time{}
+	assert.Equal(t, "renewed", commonName(t, reloader))
+
+	// A broken pair keeps the certificate that works.
+	assert.NoError(t, os.WriteFile(keyFile, []byte("half written"), 0o600))
+	reloader.checked = time.Time{}
+	assert.Equal(t, "renewed", commonName(t, reloader))
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore hides where uploaded files physically live so the local
+// filesystem can later be swapped for an S3-compatible bucket.
+type BlobStore interface {
+	Put(key string, r io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
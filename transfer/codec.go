@@ -0,0 +1,254 @@
+package transfer
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var csvHeader = []string{"slug", "title", "tag", "author", "created_at", "updated_at", "archived_at", "excerpt", "meta_description", "canonical_url", "og_image", "desc"}
+
+const frontMatterDelimiter = "---"
+
+func Encode(format string, records []Record, w io.Writer) error {
+	switch format {
+	case FormatJSONL:
+		return encodeJSONL(records, w)
+	case FormatCSV:
+		return encodeCSV(records, w)
+	case FormatMarkdown:
+		return encodeMarkdown(records, w)
+	}
+	return ErrUnknownFormat
+}
+
+func Decode(format string, data []byte) ([]Record, error) {
+	switch format {
+	case FormatJSONL:
+		return decodeJSONL(data)
+	case FormatCSV:
+		return decodeCSV(data)
+	case FormatMarkdown:
+		return decodeMarkdown(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func encodeJSONL(records []Record, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeJSONL(data []byte) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func encodeCSV(records []Record, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := writer.Write([]string{
+			record.Slug,
+			record.Title,
+			record.Tag,
+			record.Author,
+			formatTime(&record.CreatedAt),
+			formatTime(&record.UpdatedAt),
+			formatTime(record.ArchivedAt),
+			record.Excerpt,
+			record.MetaDescription,
+			record.CanonicalURL,
+			record.OGImage,
+			record.Desc,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func decodeCSV(data []byte) ([]Record, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	// Columns are looked up by header name so files edited in a
+	// spreadsheet with reordered columns still import.
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	records := make([]Record, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		record := Record{
+			Slug:            field(row, "slug"),
+			Title:           field(row, "title"),
+			Tag:             field(row, "tag"),
+			Author:          field(row, "author"),
+			Excerpt:         field(row, "excerpt"),
+			MetaDescription: field(row, "meta_description"),
+			CanonicalURL:    field(row, "canonical_url"),
+			OGImage:         field(row, "og_image"),
+			Desc:            field(row, "desc"),
+		}
+		createdAt, err := parseTime(field(row, "created_at"))
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line+2) + ": " + err.Error())
+		}
+		updatedAt, err := parseTime(field(row, "updated_at"))
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line+2) + ": " + err.Error())
+		}
+		if record.ArchivedAt, err = parseTime(field(row, "archived_at")); err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line+2) + ": " + err.Error())
+		}
+		if createdAt != nil {
+			record.CreatedAt = *createdAt
+		}
+		if updatedAt != nil {
+			record.UpdatedAt = *updatedAt
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func encodeMarkdown(records []Record, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	for _, record := range records {
+		frontMatter, err := yaml.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		file, err := archive.Create(record.Slug + ".md")
+		if err != nil {
+			return err
+		}
+		content := frontMatterDelimiter + "\n" + string(frontMatter) + frontMatterDelimiter + "\n" + record.Desc
+		if _, err := io.WriteString(file, content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func decodeMarkdown(data []byte) ([]Record, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(archive.File))
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || path.Ext(file.Name) != ".md" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := parseMarkdown(string(content))
+		if err != nil {
+			return nil, errors.New(file.Name + ": " + err.Error())
+		}
+		if record.Slug == "" {
+			record.Slug = strings.TrimSuffix(path.Base(file.Name), ".md")
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func parseMarkdown(content string) (Record, error) {
+	var record Record
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, frontMatterDelimiter+"\n") {
+		return record, errors.New("missing front-matter")
+	}
+
+	rest := content[len(frontMatterDelimiter)+1:]
+	end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n"+frontMatterDelimiter) {
+			return record, errors.New("unterminated front-matter")
+		}
+		end = len(rest) - len(frontMatterDelimiter) - 1
+	}
+
+	if err := yaml.Unmarshal([]byte(rest[:end]), &record); err != nil {
+		return record, err
+	}
+	if body := end + len(frontMatterDelimiter) + 2; body < len(rest) {
+		record.Desc = rest[body:]
+	}
+	return record, nil
+}
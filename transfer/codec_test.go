@@ -0,0 +1,65 @@
+package transfer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	archivedAt := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	records := []Record{
+		{
+			Slug:      "tupai-terbang",
+			Title:     "Tupai terbang",
+			Tag:       "fiction",
+			Author:    "rena.aliana@yahoo.com",
+			CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+			Desc:      "Tupai itu **terbang**,\n\n---\n\nke langit \"ke 100\".",
+		},
+		{
+			Slug:            "tupai-berdiri",
+			Title:           "Tupai: berdiri",
+			Tag:             "fiction",
+			Author:          "rena.aliana@yahoo.com",
+			CreatedAt:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			UpdatedAt:       time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+			ArchivedAt:      &archivedAt,
+			Excerpt:         "Tupai, berdiri.",
+			MetaDescription: "A squirrel \"standing\" east",
+			CanonicalURL:    "https://example.com/tupai-berdiri",
+			OGImage:         "https://example.com/tupai.png",
+			Desc:            "Tupai itu berdiri ke arah timur.",
+		},
+	}
+
+	for _, format := range []string{FormatJSONL, FormatCSV, FormatMarkdown} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, Encode(format, records, &buf))
+
+			decoded, err := Decode(format, buf.Bytes())
+			assert.NoError(t, err)
+			assert.Equal(t, len(records), len(decoded))
+			for i := range records {
+				assert.Equal(t, records[i].Slug, decoded[i].Slug)
+				assert.Equal(t, records[i].Title, decoded[i].Title)
+				assert.Equal(t, records[i].Desc, decoded[i].Desc)
+				assert.True(t, records[i].CreatedAt.Equal(decoded[i].CreatedAt))
+				assert.Equal(t, records[i].ArchivedAt == nil, decoded[i].ArchivedAt == nil)
+				assert.Equal(t, records[i].Excerpt, decoded[i].Excerpt)
+				assert.Equal(t, records[i].MetaDescription, decoded[i].MetaDescription)
+				assert.Equal(t, records[i].CanonicalURL, decoded[i].CanonicalURL)
+				assert.Equal(t, records[i].OGImage, decoded[i].OGImage)
+			}
+		})
+	}
+}
+
+func TestDecodeUnknownFormat(t *testing.T) {
+	_, err := Decode("xml", nil)
+	assert.Equal(t, ErrUnknownFormat, err)
+}
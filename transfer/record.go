@@ -0,0 +1,76 @@
+package transfer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/models"
+)
+
+const (
+	FormatJSONL    = "jsonl"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+var ErrUnknownFormat = errors.New("format must be one of jsonl, csv or markdown")
+
+// Record is the portable shape of an article. The author is referenced by
+// email since ids differ between environments.
+type Record struct {
+	Slug            string     `json:"slug" yaml:"slug"`
+	Title           string     `json:"title" yaml:"title"`
+	Tag             string     `json:"tag" yaml:"tag"`
+	Author          string     `json:"author" yaml:"author"`
+	CreatedAt       time.Time  `json:"created_at" yaml:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" yaml:"updated_at"`
+	ArchivedAt      *time.Time `json:"archived_at,omitempty" yaml:"archived_at,omitempty"`
+	Excerpt         string     `json:"excerpt,omitempty" yaml:"excerpt,omitempty"`
+	MetaDescription string     `json:"meta_description,omitempty" yaml:"meta_description,omitempty"`
+	CanonicalURL    string     `json:"canonical_url,omitempty" yaml:"canonical_url,omitempty"`
+	OGImage         string     `json:"og_image,omitempty" yaml:"og_image,omitempty"`
+	Desc            string     `json:"desc" yaml:"-"`
+}
+
+func NewRecord(article models.Article) Record {
+	return Record{
+		Slug:            article.Slug,
+		Title:           article.Title,
+		Tag:             article.Tag,
+		Author:          article.User.Email,
+		CreatedAt:       article.CreatedAt,
+		UpdatedAt:       article.UpdatedAt,
+		ArchivedAt:      article.ArchivedAt,
+		Excerpt:         article.Excerpt,
+		MetaDescription: article.MetaDescription,
+		CanonicalURL:    article.CanonicalURL,
+		OGImage:         article.OGImage,
+		Desc:            article.Desc,
+	}
+}
+
+func IsValidFormat(format string) bool {
+	return format == FormatJSONL || format == FormatCSV || format == FormatMarkdown
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatMarkdown:
+		return "application/zip"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+func FileExtension(format string) string {
+	switch format {
+	case FormatCSV:
+		return ".csv"
+	case FormatMarkdown:
+		return ".zip"
+	default:
+		return ".jsonl"
+	}
+}
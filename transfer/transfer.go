@@ -0,0 +1,217 @@
+package transfer
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ArdhanaGusti/Golang_api/handler/markdown"
+	"github.com/ArdhanaGusti/Golang_api/handler/slugs"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/ArdhanaGusti/Golang_api/recommend"
+	"gorm.io/gorm"
+)
+
+var errDryRun = errors.New("dry run")
+
+type ImportOptions struct {
+	DryRun bool
+	// Upsert updates articles whose slug already exists instead of
+	// skipping them.
+	Upsert bool
+	// AuthorMap rewrites author emails of the source environment to
+	// emails of this one.
+	AuthorMap map[string]string
+	// DefaultAuthor is used when an author can't be found here.
+	DefaultAuthor string
+}
+
+type ImportError struct {
+	Index   int    `json:"Index"`
+	Slug    string `json:"Slug"`
+	Message string `json:"Message"`
+}
+
+type ImportReport struct {
+	DryRun  bool          `json:"DryRun"`
+	Created int           `json:"Created"`
+	Updated int           `json:"Updated"`
+	Skipped int           `json:"Skipped"`
+	Errors  []ImportError `json:"Errors"`
+}
+
+func Export(db *gorm.DB) ([]Record, error) {
+	var articles []models.Article
+	if err := db.Preload("User").Order("id").Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(articles))
+	for _, article := range articles {
+		records = append(records, NewRecord(article))
+	}
+	return records, nil
+}
+
+// Import writes records inside one transaction. A dry run executes every
+// statement and rolls back at the end, so the report shows exactly what a
+// real import would do. Each record gets a savepoint of its own: a record
+// that fails rolls back alone, and on PostgreSQL does not abort the
+// transaction for the records after it.
+func Import(db *gorm.DB, records []Record, options ImportOptions) (ImportReport, error) {
+	report := ImportReport{DryRun: options.DryRun, Errors: []ImportError{}}
+	authors := map[string]uint{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for index, record := range records {
+			var outcome string
+			err := tx.Transaction(func(savepoint *gorm.DB) error {
+				var err error
+				outcome, err = importRecord(savepoint, record, options, authors)
+				return err
+			})
+			if err != nil {
+				report.Errors = append(report.Errors, ImportError{
+					Index:   index,
+					Slug:    record.Slug,
+					Message: err.Error(),
+				})
+				continue
+			}
+			switch outcome {
+			case "created":
+				report.Created++
+			case "updated":
+				report.Updated++
+			default:
+				report.Skipped++
+			}
+		}
+
+		if options.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return report, err
+	}
+	return report, nil
+}
+
+func resolveAuthor(tx *gorm.DB, email string, options ImportOptions, authors map[string]uint) (uint, error) {
+	if mapped, ok := options.AuthorMap[email]; ok {
+		email = mapped
+	}
+	if id, ok := authors[email]; ok {
+		return id, nil
+	}
+
+	var user models.User
+	err := tx.Select("id").First(&user, "LOWER(email) = LOWER(?)", email).Error
+	if err != nil && options.DefaultAuthor != "" {
+		err = tx.Select("id").First(&user, "LOWER(email) = LOWER(?)", options.DefaultAuthor).Error
+	}
+	if err != nil {
+		return 0, errors.New("Author " + email + " don't exist")
+	}
+
+	authors[email] = user.ID
+	return user.ID, nil
+}
+
+func importRecord(tx *gorm.DB, record Record, options ImportOptions, authors map[string]uint) (string, error) {
+	if record.Title == "" || record.Desc == "" || record.Tag == "" {
+		return "", errors.New("Title, Desc and Tag are required")
+	}
+
+	userID, err := resolveAuthor(tx, record.Author, options, authors)
+	if err != nil {
+		return "", err
+	}
+
+	descHTML, err := markdown.Render(record.Desc)
+	if err != nil {
+		return "", err
+	}
+
+	if record.Slug != "" {
+		slug := slugs.Normalize(record.Slug)
+		if slug == "" {
+			return "", errors.New("Slug " + record.Slug + " is not valid")
+		}
+		record.Slug = slug
+
+		var existing models.Article
+		if err := tx.Unscoped().First(&existing, "slug = ?", record.Slug).Error; err == nil {
+			if !options.Upsert {
+				return "skipped", nil
+			}
+			return "updated", tx.Unscoped().Model(&existing).Updates(map[string]interface{}{
+				"title":            record.Title,
+				"desc":             record.Desc,
+				"desc_html":        descHTML,
+				"tag":              recommend.NormalizeTags(record.Tag),
+				"user_id":          userID,
+				"archived_at":      record.ArchivedAt,
+				"excerpt":          record.Excerpt,
+				"meta_description": record.MetaDescription,
+				"canonical_url":    record.CanonicalURL,
+				"og_image":         record.OGImage,
+				"version":          gorm.Expr("version + 1"),
+			}).Error
+		}
+
+		redirects, err := slugs.Redirects(tx, record.Slug)
+		if err != nil {
+			return "", err
+		}
+		if redirects {
+			return "", errors.New("Slug " + record.Slug + " redirects to another article")
+		}
+	}
+
+	article := models.Article{
+		Title:           record.Title,
+		Desc:            record.Desc,
+		DescHTML:        descHTML,
+		Tag:             recommend.NormalizeTags(record.Tag),
+		Slug:            record.Slug,
+		UserID:          userID,
+		ArchivedAt:      record.ArchivedAt,
+		Excerpt:         record.Excerpt,
+		MetaDescription: record.MetaDescription,
+		CanonicalURL:    record.CanonicalURL,
+		OGImage:         record.OGImage,
+	}
+	if !record.CreatedAt.IsZero() {
+		article.CreatedAt = record.CreatedAt
+	}
+	if !record.UpdatedAt.IsZero() {
+		article.UpdatedAt = record.UpdatedAt
+	} else {
+		article.UpdatedAt = time.Now()
+	}
+
+	if article.Slug == "" {
+		return "created", slugs.Create(tx, &article)
+	}
+	return "created", tx.Create(&article).Error
+}
+
+// ParseAuthorMap reads "old@example.com=new@example.com,..." pairs.
+func ParseAuthorMap(value string) (map[string]string, error) {
+	authorMap := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || from == "" || to == "" {
+			return nil, errors.New("author map entry " + pair + " must look like old=new")
+		}
+		authorMap[strings.TrimSpace(from)] = strings.TrimSpace(to)
+	}
+	return authorMap, nil
+}
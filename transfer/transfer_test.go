@@ -0,0 +1,71 @@
+package transfer
+
+import (
+	"testing"
+
+	"github.com/ArdhanaGusti/Golang_api/migrations"
+	"github.com/ArdhanaGusti/Golang_api/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestImportKeepsGoingAfterFailedRecord(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	_, err = migrations.Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Create(&models.User{Username: "Rena", Email: "rena.aliana@yahoo.com"}).Error)
+	// A statement failing in the database, not in Go, is what aborts a
+	// PostgreSQL transaction.
+	assert.NoError(t, db.Exec("CREATE TRIGGER broken BEFORE INSERT ON articles WHEN NEW.title = 'Broken' BEGIN SELECT RAISE(ABORT, 'broken'); END").Error)
+
+	records := []Record{
+		{Slug: "first", Title: "First", Tag: "go", Author: "rena.aliana@yahoo.com", Desc: "One"},
+		{Slug: "broken", Title: "Broken", Tag: "go", Author: "rena.aliana@yahoo.com", Desc: "Two"},
+		{Slug: "third", Title: "Third", Tag: "go", Author: "rena.aliana@yahoo.com", Desc: "Three"},
+	}
+	report, err := Import(db, records, ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, "broken", report.Errors[0].Slug)
+	}
+
+	var slugs []string
+	assert.NoError(t, db.Model(&models.Article{}).Order("id").Pluck("slug", &slugs).Error)
+	assert.Equal(t, []string{"first", "third"}, slugs)
+}
+
+func TestImportChecksSlugs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	_, err = migrations.Up(db, "sqlite", 0)
+	assert.NoError(t, err)
+	user := models.User{Username: "Rena", Email: "rena.aliana@yahoo.com"}
+	assert.NoError(t, db.Create(&user).Error)
+	renamed := models.Article{Title: "Tupai berdiri", Slug: "tupai-berdiri", UserID: user.ID}
+	assert.NoError(t, db.Create(&renamed).Error)
+	assert.NoError(t, db.Create(&models.SlugHistory{Slug: "tupai-terbang", ArticleID: renamed.ID}).Error)
+
+	records := []Record{
+		{Slug: "Tupai Lompat!", Title: "Tupai lompat", Tag: "go", Author: "rena.aliana@yahoo.com", Desc: "One"},
+		{Slug: "../../", Title: "Dots", Tag: "go", Author: "rena.aliana@yahoo.com", Desc: "Two"},
+		{Slug: "tupai-terbang", Title: "Tupai terbang", Tag: "go", Author: "rena.aliana@yahoo.com", Desc: "Three"},
+	}
+	report, err := Import(db, records, ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	if assert.Len(t, report.Errors, 2) {
+		assert.Equal(t, 1, report.Errors[0].Index)
+		assert.Equal(t, 2, report.Errors[1].Index)
+	}
+
+	var slugs []string
+	assert.NoError(t, db.Model(&models.Article{}).Order("id").Pluck("slug", &slugs).Error)
+	assert.Equal(t, []string{"tupai-berdiri", "tupai-lompat"}, slugs)
+}